@@ -0,0 +1,191 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//              http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sear
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/blevesearch/vellum"
+	velreg "github.com/blevesearch/vellum/regexp"
+)
+
+type levKey struct {
+	term      string
+	fuzziness uint8
+}
+
+type regexpKey struct {
+	regexp    string
+	maxStates int
+}
+
+// DefaultAutomatonCacheSize is the capacity of the SharedAutomatonCache,
+// for each of regexp and Levenshtein automata.
+const DefaultAutomatonCacheSize = 1024
+
+// AutomatonCache is a goroutine-safe cache of compiled regexp
+// and Levenshtein automata.  Compiled automata are immutable,
+// so a single cache can be shared by many Sear instances, each
+// used by a different goroutine, to avoid compiling the same
+// query parts once per instance.
+type AutomatonCache struct {
+	m       sync.Mutex
+	regexps *lruCache[regexpKey, *compiledRegexp]
+	levs    *lruCache[levKey, vellum.Automaton]
+
+	regexpHits   atomic.Uint64
+	regexpMisses atomic.Uint64
+	levHits      atomic.Uint64
+	levMisses    atomic.Uint64
+}
+
+// AutomatonCacheStats are the hits and misses of an AutomatonCache,
+// and the number of automata it currently holds, for each of
+// regexp and Levenshtein automata.
+type AutomatonCacheStats struct {
+	RegexpHits    uint64
+	RegexpMisses  uint64
+	RegexpEntries int
+
+	LevenshteinHits    uint64
+	LevenshteinMisses  uint64
+	LevenshteinEntries int
+}
+
+// NewAutomatonCache returns a new, empty, unbounded AutomatonCache.
+func NewAutomatonCache() *AutomatonCache {
+	return NewAutomatonCacheWithCapacity(0)
+}
+
+// NewAutomatonCacheWithCapacity returns a new, empty AutomatonCache,
+// which holds at most capacity regexp and capacity Levenshtein automata,
+// evicting the least recently used.  Zero capacity means unbounded.
+func NewAutomatonCacheWithCapacity(capacity int) *AutomatonCache {
+	return &AutomatonCache{
+		regexps: newLRUCache[regexpKey, *compiledRegexp](capacity),
+		levs:    newLRUCache[levKey, vellum.Automaton](capacity),
+	}
+}
+
+var sharedAutomatonCache struct {
+	once sync.Once
+	c    *AutomatonCache
+}
+
+// SharedAutomatonCache returns the process-wide AutomatonCache,
+// with capacity DefaultAutomatonCacheSize, unless changed with
+// SetCapacity.
+func SharedAutomatonCache() *AutomatonCache {
+	sharedAutomatonCache.once.Do(func() {
+		sharedAutomatonCache.c = NewAutomatonCacheWithCapacity(DefaultAutomatonCacheSize)
+	})
+	return sharedAutomatonCache.c
+}
+
+// SetCapacity changes the number of regexp and of Levenshtein
+// automata held, evicting the least recently used beyond it.
+// Zero capacity means unbounded.
+func (c *AutomatonCache) SetCapacity(capacity int) {
+	c.m.Lock()
+	c.regexps.SetCapacity(capacity)
+	c.levs.SetCapacity(capacity)
+	c.m.Unlock()
+}
+
+// Regexp returns the compiled regexp, compiling and
+// caching it if necessary, within vellum's default limits.
+func (c *AutomatonCache) Regexp(regexStr string) (*velreg.Regexp, error) {
+	return c.RegexpWithLimit(regexStr, velreg.StateLimit)
+}
+
+// RegexpWithLimit returns the compiled regexp, compiling and caching
+// it if necessary, or a RegexpLimitError if its DFA has more than
+// maxStates states.  Errors are cached too, so a regexp which exceeds
+// the limit is only compiled once.
+func (c *AutomatonCache) RegexpWithLimit(regexStr string, maxStates int) (*velreg.Regexp, error) {
+	cr := c.compiledRegexp(regexStr, maxStates)
+	return cr.regex, cr.err
+}
+
+// compiledRegexp returns the cached regexp, with its literal prefix,
+// compiling and caching it if necessary
+func (c *AutomatonCache) compiledRegexp(regexStr string, maxStates int) *compiledRegexp {
+	key := regexpKey{regexp: regexStr, maxStates: maxStates}
+	c.m.Lock()
+	rv, ok := c.regexps.Get(key)
+	c.m.Unlock()
+	if ok {
+		c.regexpHits.Add(1)
+		return rv
+	}
+	c.regexpMisses.Add(1)
+
+	// compile outside the lock, concurrent misses may both compile,
+	// but the results are equivalent, and the first one stored wins
+	rv = newCompiledRegexp(regexStr, maxStates)
+
+	c.m.Lock()
+	if prev, ok := c.regexps.Get(key); ok {
+		rv = prev
+	} else {
+		c.regexps.Put(key, rv)
+	}
+	c.m.Unlock()
+	return rv
+}
+
+// LevenshteinAutomaton returns the Levenshtein automaton for
+// term and fuzziness, building and caching it if necessary.
+func (c *AutomatonCache) LevenshteinAutomaton(term string, fuzziness uint8) (vellum.Automaton, error) {
+	key := levKey{term: term, fuzziness: fuzziness}
+	c.m.Lock()
+	rv, ok := c.levs.Get(key)
+	c.m.Unlock()
+	if ok {
+		c.levHits.Add(1)
+		return rv, nil
+	}
+	c.levMisses.Add(1)
+
+	rv, err := getLevAutomaton(term, fuzziness)
+	if err != nil {
+		return nil, err
+	}
+
+	c.m.Lock()
+	if prev, ok := c.levs.Get(key); ok {
+		rv = prev
+	} else {
+		c.levs.Put(key, rv)
+	}
+	c.m.Unlock()
+	return rv, nil
+}
+
+// Stats returns the current stats of this cache.
+func (c *AutomatonCache) Stats() AutomatonCacheStats {
+	c.m.Lock()
+	regexpEntries, levEntries := c.regexps.Len(), c.levs.Len()
+	c.m.Unlock()
+	return AutomatonCacheStats{
+		RegexpHits:         c.regexpHits.Load(),
+		RegexpMisses:       c.regexpMisses.Load(),
+		RegexpEntries:      regexpEntries,
+		LevenshteinHits:    c.levHits.Load(),
+		LevenshteinMisses:  c.levMisses.Load(),
+		LevenshteinEntries: levEntries,
+	}
+}
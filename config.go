@@ -0,0 +1,234 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//              http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sear
+
+import (
+	"fmt"
+
+	velreg "github.com/blevesearch/vellum/regexp"
+)
+
+// Config keys understood by New.  Other keys are ignored, as the
+// same config map is shared with the rest of the bleve index config.
+const (
+	// ConfigMaxDocs is the number of documents the index can hold,
+	// values above 1 enable multi-document mode (default 1).
+	ConfigMaxDocs = "max_docs"
+
+	// ConfigRegexpCacheSize is the number of compiled regexps cached
+	// by the reader, least recently used first evicted, 0 means
+	// unlimited (default 128).
+	ConfigRegexpCacheSize = "regexp_cache_size"
+
+	// ConfigRegexpMaxPatternLength is the longest regexp, in bytes,
+	// accepted by regexp dictionary lookups, 0 means unlimited
+	// (default 0).
+	ConfigRegexpMaxPatternLength = "regexp_max_pattern_length"
+
+	// ConfigRegexpMaxDFAStates is the largest number of DFA states a
+	// compiled regexp may have, at most vellum's regexp.StateLimit,
+	// which also bounds compilation (default regexp.StateLimit).
+	// The size of the compiled program is limited in proportion,
+	// and regexps exceeding the limit are cached with their error.
+	ConfigRegexpMaxDFAStates = "regexp_max_dfa_states"
+
+	// ConfigLevenshteinCacheSize is the number of Levenshtein automata
+	// cached by the reader, least recently used first evicted, 0 means
+	// unlimited (default 128).
+	ConfigLevenshteinCacheSize = "levenshtein_cache_size"
+
+	// ConfigSharedAutomatonCache controls whether readers compile
+	// automata through the process-wide SharedAutomatonCache, so that
+	// they are only compiled once per process (default false).
+	ConfigSharedAutomatonCache = "shared_automaton_cache"
+
+	// ConfigMaxFuzziness is the largest fuzziness accepted by
+	// fuzzy dictionary lookups, at most MaxFuzziness (default 2).
+	// Lookups beyond 2 are evaluated without a vellum automaton.
+	ConfigMaxFuzziness = "max_fuzziness"
+
+	// ConfigMaxTermsPerField is the largest number of unique terms
+	// a field may have, Update returns an error for documents which
+	// exceed it, 0 means unlimited (default 0).
+	ConfigMaxTermsPerField = "max_terms_per_field"
+
+	// ConfigTrackTermVectors controls whether term field readers
+	// return term vectors when requested (default true).
+	ConfigTrackTermVectors = "track_term_vectors"
+
+	// ConfigStatsEnabled controls whether stats are tracked (default true).
+	ConfigStatsEnabled = "stats_enabled"
+
+	// ConfigStrictDelete controls whether Delete in single document
+	// mode only deletes a document with the same id (default false).
+	ConfigStrictDelete = "strict_delete"
+
+	// ConfigIsolatedReaders controls whether Reader returns isolated
+	// snapshot readers (default false).
+	ConfigIsolatedReaders = "isolated_readers"
+
+	// ConfigValidateVectors controls whether Update returns an error
+	// for documents with vector fields which can never match, such as
+	// those with NaN values, rather than indexing them (default false).
+	// bleve drops vectors which do not match the mapping's dims before
+	// Update, which only ConfigVectorFields detects.
+	ConfigValidateVectors = "validate_vectors"
+
+	// ConfigVectorFields is the vector fields every document must have,
+	// with the vectors build tag.  It maps each field name to a map of
+	// "dims" and optionally "similarity", and Update returns an error
+	// for documents without the field, or with other dims or similarity
+	// (default none).
+	ConfigVectorFields = "vector_fields"
+
+	// ConfigStreamingCorpusStats controls whether corpus stats are
+	// accumulated from the documents indexed, see StreamingCorpusStats
+	// (default false).
+	ConfigStreamingCorpusStats = "streaming_corpus_stats"
+
+	// ConfigStreamingCorpusStatsWindow is the number of most recent
+	// documents reflected by streaming corpus stats, 0 means all
+	// documents (default 0).
+	ConfigStreamingCorpusStatsWindow = "streaming_corpus_stats_window"
+)
+
+// MaxFuzziness is the largest value accepted for ConfigMaxFuzziness,
+// edit distances are reported as uint8.
+const MaxFuzziness = 255
+
+type config struct {
+	maxDocs          int
+	regexpCacheSize  int
+	levCacheSize     int
+	maxFuzziness     int
+	maxTermsPerField int
+	trackTermVectors bool
+	statsEnabled     bool
+	strictDelete     bool
+	isolatedReaders  bool
+	validateVectors  bool
+	sharedAutomata   bool
+
+	regexpMaxPatternLength int
+	regexpMaxDFAStates     int
+
+	streamingCorpusStats       bool
+	streamingCorpusStatsWindow int
+
+	// sorted by name
+	vectorFields []vectorFieldConfig
+}
+
+// vectorFieldConfig is a vector field every document must have
+type vectorFieldConfig struct {
+	name       string
+	dims       int
+	similarity string // any if empty
+}
+
+func defaultConfig() config {
+	return config{
+		maxDocs:          1,
+		regexpCacheSize:  128,
+		levCacheSize:     128,
+		maxFuzziness:     2,
+		trackTermVectors: true,
+		statsEnabled:     true,
+
+		regexpMaxDFAStates: velreg.StateLimit,
+	}
+}
+
+func parseConfig(m map[string]interface{}) (config, error) {
+	rv := defaultConfig()
+	ints := []struct {
+		key      string
+		val      *int
+		min, max int
+	}{
+		{ConfigMaxDocs, &rv.maxDocs, 1, MaxDocs},
+		{ConfigRegexpCacheSize, &rv.regexpCacheSize, 0, -1},
+		{ConfigRegexpMaxPatternLength, &rv.regexpMaxPatternLength, 0, -1},
+		{ConfigRegexpMaxDFAStates, &rv.regexpMaxDFAStates, 1, velreg.StateLimit},
+		{ConfigLevenshteinCacheSize, &rv.levCacheSize, 0, -1},
+		{ConfigMaxFuzziness, &rv.maxFuzziness, 0, MaxFuzziness},
+		{ConfigMaxTermsPerField, &rv.maxTermsPerField, 0, -1},
+		{ConfigStreamingCorpusStatsWindow, &rv.streamingCorpusStatsWindow, 0, -1},
+	}
+	for _, opt := range ints {
+		v, ok := m[opt.key]
+		if !ok {
+			continue
+		}
+		i, err := configInt(v)
+		if err != nil {
+			return rv, fmt.Errorf("invalid config %s: %v", opt.key, err)
+		}
+		if i < opt.min || (opt.max >= 0 && i > opt.max) {
+			return rv, fmt.Errorf("invalid config %s: %d out of range", opt.key, i)
+		}
+		*opt.val = i
+	}
+
+	bools := []struct {
+		key string
+		val *bool
+	}{
+		{ConfigTrackTermVectors, &rv.trackTermVectors},
+		{ConfigStatsEnabled, &rv.statsEnabled},
+		{ConfigStrictDelete, &rv.strictDelete},
+		{ConfigIsolatedReaders, &rv.isolatedReaders},
+		{ConfigValidateVectors, &rv.validateVectors},
+		{ConfigSharedAutomatonCache, &rv.sharedAutomata},
+		{ConfigStreamingCorpusStats, &rv.streamingCorpusStats},
+	}
+	for _, opt := range bools {
+		v, ok := m[opt.key]
+		if !ok {
+			continue
+		}
+		b, ok := v.(bool)
+		if !ok {
+			return rv, fmt.Errorf("invalid config %s: expected bool, got %T", opt.key, v)
+		}
+		*opt.val = b
+	}
+
+	if v, ok := m[ConfigVectorFields]; ok {
+		fields, err := parseVectorFields(v)
+		if err != nil {
+			return rv, fmt.Errorf("invalid config %s: %v", ConfigVectorFields, err)
+		}
+		rv.vectorFields = fields
+	}
+	return rv, nil
+}
+
+// configInt accepts integer values, including those decoded
+// from JSON as float64
+func configInt(v interface{}) (int, error) {
+	switch n := v.(type) {
+	case int:
+		return n, nil
+	case int64:
+		return int(n), nil
+	case float64:
+		if n != float64(int(n)) {
+			return 0, fmt.Errorf("expected integer, got %v", n)
+		}
+		return int(n), nil
+	}
+	return 0, fmt.Errorf("expected integer, got %T", v)
+}
@@ -0,0 +1,215 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//              http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sear
+
+import (
+	"fmt"
+	"testing"
+
+	index "github.com/blevesearch/bleve_index_api"
+	velreg "github.com/blevesearch/vellum/regexp"
+)
+
+func TestConfigInvalid(t *testing.T) {
+	tests := []map[string]interface{}{
+		{ConfigMaxDocs: 0},
+		{ConfigMaxDocs: MaxDocs + 1},
+		{ConfigMaxDocs: "two"},
+		{ConfigRegexpCacheSize: -1},
+		{ConfigRegexpMaxPatternLength: -1},
+		{ConfigRegexpMaxDFAStates: 0},
+		{ConfigRegexpMaxDFAStates: velreg.StateLimit + 1},
+		{ConfigLevenshteinCacheSize: -1},
+		{ConfigMaxFuzziness: MaxFuzziness + 1},
+		{ConfigMaxFuzziness: 1.5},
+		{ConfigMaxTermsPerField: -1},
+		{ConfigTrackTermVectors: "yes"},
+		{ConfigStatsEnabled: 1},
+		{ConfigStrictDelete: nil},
+		{ConfigIsolatedReaders: "true"},
+		{ConfigValidateVectors: "false"},
+		{ConfigVectorFields: "vec"},
+	}
+	for _, config := range tests {
+		_, err := New("", config, nil)
+		if err == nil {
+			t.Errorf("expected error for config %v", config)
+		}
+	}
+
+	// values decoded from JSON, and unrelated keys
+	idx, err := New("", map[string]interface{}{
+		ConfigMaxDocs:      float64(4),
+		ConfigStatsEnabled: false,
+		"forceSegmentType": "zap",
+	}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s := idx.(*Sear)
+	if s.config.maxDocs != 4 || s.config.statsEnabled {
+		t.Errorf("expected config to be applied, got %+v", s.config)
+	}
+}
+
+func TestConfigOptions(t *testing.T) {
+	idx, err := New("", map[string]interface{}{
+		ConfigMaxFuzziness:     1,
+		ConfigMaxTermsPerField: 3,
+		ConfigTrackTermVectors: false,
+		ConfigStatsEnabled:     false,
+		ConfigStrictDelete:     true,
+		ConfigIsolatedReaders:  true,
+		ConfigRegexpCacheSize:  2,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := idx.(*Sear)
+
+	// max terms per field
+	bleveDoc := newTestDoc("a")
+	bleveDoc.AddField(newTestField("name", []byte("one two three four")))
+	err = idx.Update(bleveDoc)
+	if err == nil {
+		t.Errorf("expected error indexing document exceeding max terms per field")
+	}
+	if len(s.docs) != 0 {
+		t.Errorf("expected rejected document to be removed, got %d docs", len(s.docs))
+	}
+	mapAndUpdateDocument(t, idx, "a", map[string]interface{}{
+		"name": "gas",
+	})
+
+	// isolated readers
+	reader, err := idx.Reader()
+	if err != nil {
+		t.Fatalf("error getting index reader: %v", err)
+	}
+	if reader == s.reader {
+		t.Errorf("expected isolated reader")
+	}
+
+	// term vectors are not tracked
+	tfr, err := reader.TermFieldReader(nil, []byte("gas"), "name", true, true, true)
+	if err != nil {
+		t.Fatalf("error getting term field reader: %v", err)
+	}
+	tfd, err := tfr.Next(nil)
+	if err != nil || tfd == nil {
+		t.Fatalf("expected term field doc, err: %v", err)
+	}
+	if len(tfd.Vectors) != 0 {
+		t.Errorf("expected no term vectors, got %d", len(tfd.Vectors))
+	}
+
+	// max fuzziness
+	_, err = reader.(index.IndexReaderFuzzy).FieldDictFuzzy("name", "gap", 2, "")
+	if err == nil {
+		t.Errorf("expected error for fuzziness exceeding max")
+	}
+	_, _, err = reader.(index.IndexReaderFuzzy).FieldDictFuzzyAutomaton("name", "gap", 2, "")
+	if err == nil {
+		t.Errorf("expected error for fuzziness exceeding max")
+	}
+	fd, err := reader.(index.IndexReaderFuzzy).FieldDictFuzzy("name", "gap", 1, "")
+	if err != nil {
+		t.Fatalf("error getting field dict fuzzy: %v", err)
+	}
+	assertTermDictionary(t, fd, []string{"gas"})
+
+	// regexp cache size
+	for _, re := range []string{"a.*", "b.*", "c.*"} {
+		_, err = reader.(index.IndexReaderRegexp).FieldDictRegexp("name", re)
+		if err != nil {
+			t.Fatalf("error getting field dict regexp: %v", err)
+		}
+		if s.reader.velregCache.Len() > 2 {
+			t.Errorf("expected at most 2 cached regexps, got %d", s.reader.velregCache.Len())
+		}
+	}
+
+	// stats disabled
+	if s.Stats() != (Stats{}) {
+		t.Errorf("expected empty stats, got %+v", s.Stats())
+	}
+
+	// strict delete
+	err = idx.Delete("b")
+	if err != nil {
+		t.Fatalf("error deleting doc: %v", err)
+	}
+	if len(s.docs) != 1 {
+		t.Errorf("expected strict delete of other id to leave the document")
+	}
+	err = idx.Delete("a")
+	if err != nil {
+		t.Fatalf("error deleting doc: %v", err)
+	}
+	if len(s.docs) != 0 {
+		t.Errorf("expected strict delete of same id to delete the document")
+	}
+}
+
+func TestBatchAnalysisQueue(t *testing.T) {
+	queue := index.NewAnalysisQueue(4)
+	defer queue.Close()
+
+	idx, err := New("", map[string]interface{}{
+		ConfigMaxDocs:          MaxDocs,
+		ConfigMaxTermsPerField: 2,
+	}, queue)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	batch := index.NewBatch()
+	for i := 0; i < 50; i++ {
+		doc := newTestDoc(fmt.Sprintf("%02d", i))
+		doc.AddField(newTestField("name", []byte(fmt.Sprintf("name%d", i))))
+		batch.Update(doc)
+	}
+	// exceeds max terms per field
+	doc := newTestDoc("xx")
+	doc.AddField(newTestField("name", []byte("one two three")))
+	batch.Update(doc)
+
+	err = idx.Batch(batch)
+	if err == nil {
+		t.Errorf("expected error for document exceeding max terms per field")
+	}
+
+	reader, err := idx.Reader()
+	if err != nil {
+		t.Fatalf("error getting index reader: %v", err)
+	}
+	count, _ := reader.DocCount()
+	if count != 50 {
+		t.Fatalf("expected 50 documents, got %d", count)
+	}
+	for i := 0; i < 50; i++ {
+		intID, err := reader.InternalID(fmt.Sprintf("%02d", i))
+		if err != nil {
+			t.Fatalf("error getting internal id: %v", err)
+		}
+		tfr, err := reader.TermFieldReader(nil, []byte(fmt.Sprintf("name%d", i)), "name", false, false, false)
+		if err != nil {
+			t.Fatalf("error getting term field reader: %v", err)
+		}
+		assertTermFieldReader(t, tfr, []*index.TermFieldDoc{
+			{Term: fmt.Sprintf("name%d", i), ID: intID},
+		})
+	}
+}
@@ -0,0 +1,227 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//              http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sear
+
+import (
+	"sync"
+)
+
+// CorpusStats describes a larger corpus of documents.  When configured,
+// the reader reports these statistics instead of those of the few
+// documents it holds, so that scores computed by bleve (which depend
+// on the document count, term document frequencies and, for BM25,
+// field cardinality) match the scores the document would receive
+// in that corpus.
+type CorpusStats interface {
+	// DocCount returns the number of documents in the corpus.
+	DocCount() uint64
+
+	// DocFreq returns the number of documents in the corpus
+	// which use term in the named field.
+	DocFreq(field, term string) uint64
+
+	// FieldCardinality returns the number of unique terms in the
+	// named field across the corpus, as scorch reports it through
+	// index.BM25Reader.  bleve divides it by DocCount, rounding up,
+	// for the average field length used by BM25 scoring.
+	FieldCardinality(field string) int
+}
+
+// StaticCorpusStats is a CorpusStats with fixed values, typically
+// exported from a production index.  Terms and fields which are
+// not present report 0.
+type StaticCorpusStats struct {
+	Docs               uint64
+	DocFreqs           map[string]map[string]uint64 // field -> term -> doc freq
+	FieldCardinalities map[string]int
+}
+
+func (c *StaticCorpusStats) DocCount() uint64 {
+	return c.Docs
+}
+
+func (c *StaticCorpusStats) DocFreq(field, term string) uint64 {
+	return c.DocFreqs[field][term]
+}
+
+func (c *StaticCorpusStats) FieldCardinality(field string) int {
+	return c.FieldCardinalities[field]
+}
+
+// SetCorpusStats configures the statistics reported by this
+// index's readers, nil restores the statistics of the documents
+// actually indexed.
+func (s *Sear) SetCorpusStats(c CorpusStats) {
+	s.corpus = c
+}
+
+// FieldCardinality is used by bleve, along with DocCount, to derive
+// the average field length for BM25 scoring.  Without corpus stats
+// this is the number of unique terms in the field, like scorch.
+func (r *Reader) FieldCardinality(field string) (int, error) {
+	if corpus := r.corpusStats(); corpus != nil {
+		return corpus.FieldCardinality(field), nil
+	}
+	if len(r.ds.docs) == 0 {
+		return 0, nil
+	}
+	terms, err := r.ds.SortedTermsForField(field)
+	if err != nil {
+		// only error is field doesn't exist in doc
+		return 0, nil
+	}
+	return len(terms), nil
+}
+
+// CorpusObserver is implemented by CorpusStats which learn from the
+// documents indexed.  Observe is called by Update and Batch with
+// each document once it has been analyzed.
+type CorpusObserver interface {
+	Observe(d *Document)
+}
+
+// StreamingCorpusStats accumulates corpus statistics from the
+// stream of documents observed, optionally limited to a window
+// of the most recent documents.  Statistics include the document
+// currently indexed, once it has been observed.  It is safe to
+// read concurrently with Observe, as snapshot readers may.
+type StreamingCorpusStats struct {
+	m sync.RWMutex
+
+	window int
+	docs   uint64
+	fields map[string]*streamingFieldStats
+
+	// the documents within the window, only kept when window > 0
+	ring []streamingDoc
+	next int
+}
+
+type streamingFieldStats struct {
+	docs     uint64 // documents using the field
+	docFreqs map[string]uint64
+}
+
+type streamingDoc []streamingDocField
+
+type streamingDocField struct {
+	name  string
+	terms []string
+}
+
+// NewStreamingCorpusStats returns a StreamingCorpusStats which
+// reflects the last window documents observed, or if window is 0,
+// all documents observed.
+func NewStreamingCorpusStats(window int) *StreamingCorpusStats {
+	return &StreamingCorpusStats{
+		window: window,
+		fields: make(map[string]*streamingFieldStats),
+	}
+}
+
+func (c *StreamingCorpusStats) Observe(d *Document) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	var sd streamingDoc
+	if c.window > 0 {
+		if len(c.ring) < c.window {
+			c.ring = append(c.ring, nil)
+		} else {
+			c.forget(c.ring[c.next])
+		}
+		sd = c.ring[c.next][:0]
+	}
+
+	c.docs++
+	for i, name := range d.fieldNames {
+		fs := c.fields[name]
+		if fs == nil {
+			fs = &streamingFieldStats{
+				docFreqs: make(map[string]uint64),
+			}
+			c.fields[name] = fs
+		}
+		fs.docs++
+		for term := range d.fieldTokenFreqs[i] {
+			fs.docFreqs[term]++
+		}
+
+		if c.window > 0 {
+			// reuse the slices of the document which left the window
+			if len(sd) < cap(sd) {
+				sd = sd[:len(sd)+1]
+			} else {
+				sd = append(sd, streamingDocField{})
+			}
+			f := &sd[len(sd)-1]
+			f.name, f.terms = name, f.terms[:0]
+			for term := range d.fieldTokenFreqs[i] {
+				f.terms = append(f.terms, term)
+			}
+		}
+	}
+
+	if c.window > 0 {
+		c.ring[c.next] = sd
+		c.next = (c.next + 1) % c.window
+	}
+}
+
+// forget removes a document which has left the window
+func (c *StreamingCorpusStats) forget(sd streamingDoc) {
+	c.docs--
+	for _, f := range sd {
+		fs := c.fields[f.name]
+		fs.docs--
+		if fs.docs == 0 {
+			delete(c.fields, f.name)
+			continue
+		}
+		for _, term := range f.terms {
+			if fs.docFreqs[term] <= 1 {
+				delete(fs.docFreqs, term)
+			} else {
+				fs.docFreqs[term]--
+			}
+		}
+	}
+}
+
+func (c *StreamingCorpusStats) DocCount() uint64 {
+	c.m.RLock()
+	defer c.m.RUnlock()
+	return c.docs
+}
+
+func (c *StreamingCorpusStats) DocFreq(field, term string) uint64 {
+	c.m.RLock()
+	defer c.m.RUnlock()
+	if fs := c.fields[field]; fs != nil {
+		return fs.docFreqs[term]
+	}
+	return 0
+}
+
+// FieldCardinality returns the number of unique terms in the
+// field, in the documents observed, as scorch would report it.
+func (c *StreamingCorpusStats) FieldCardinality(field string) int {
+	c.m.RLock()
+	defer c.m.RUnlock()
+	if fs := c.fields[field]; fs != nil {
+		return len(fs.docFreqs)
+	}
+	return 0
+}
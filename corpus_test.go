@@ -0,0 +1,294 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//              http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sear
+
+import (
+	"math"
+	"testing"
+
+	index "github.com/blevesearch/bleve_index_api"
+)
+
+func TestCorpusStats(t *testing.T) {
+	idx, err := New("", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := idx.(*Sear)
+
+	mapAndUpdateDocument(t, idx, "a", map[string]interface{}{
+		"name":   "marty",
+		"slogan": "code match code",
+	})
+
+	reader, err := idx.Reader()
+	if err != nil {
+		t.Fatalf("error getting index reader: %v", err)
+	}
+	br, ok := reader.(index.BM25Reader)
+	if !ok {
+		t.Fatalf("expected reader to implement BM25Reader")
+	}
+
+	// without corpus stats, the document's own stats are reported
+	count, err := reader.DocCount()
+	if err != nil || count != 1 {
+		t.Errorf("expected doc count 1, got %d, err: %v", count, err)
+	}
+	tfr, err := reader.TermFieldReader(nil, []byte("code"), "slogan", true, true, false)
+	if err != nil {
+		t.Fatalf("error getting term field reader: %v", err)
+	}
+	if tfr.Count() != 1 {
+		t.Errorf("expected term count 1, got %d", tfr.Count())
+	}
+	card, err := br.FieldCardinality("slogan")
+	if err != nil || card != 2 {
+		t.Errorf("expected field cardinality 2, got %d, err: %v", card, err)
+	}
+	card, err = br.FieldCardinality("missing")
+	if err != nil || card != 0 {
+		t.Errorf("expected field cardinality 0, got %d, err: %v", card, err)
+	}
+
+	s.SetCorpusStats(&StaticCorpusStats{
+		Docs: 1000,
+		DocFreqs: map[string]map[string]uint64{
+			"slogan": {"code": 40, "match": 7},
+		},
+		FieldCardinalities: map[string]int{
+			"slogan": 4500,
+		},
+	})
+
+	count, err = reader.DocCount()
+	if err != nil || count != 1000 {
+		t.Errorf("expected doc count 1000, got %d, err: %v", count, err)
+	}
+	tfr, err = reader.TermFieldReader(nil, []byte("code"), "slogan", true, true, false)
+	if err != nil {
+		t.Fatalf("error getting term field reader: %v", err)
+	}
+	if tfr.Count() != 40 {
+		t.Errorf("expected term count 40, got %d", tfr.Count())
+	}
+	// postings are still those of the indexed document
+	tfd, err := tfr.Next(nil)
+	if err != nil || tfd == nil || tfd.Freq != 2 || tfd.Norm != normForLen(3) {
+		t.Errorf("unexpected term field doc %v, err: %v", tfd, err)
+	}
+	tfd, err = tfr.Next(nil)
+	if err != nil || tfd != nil {
+		t.Errorf("expected end of postings, got %v, err: %v", tfd, err)
+	}
+	tfr, err = reader.TermFieldReader(nil, []byte("other"), "slogan", true, true, false)
+	if err != nil {
+		t.Fatalf("error getting term field reader: %v", err)
+	}
+	assertTermFieldReaderEmpty(t, tfr)
+
+	card, err = br.FieldCardinality("slogan")
+	if err != nil || card != 4500 {
+		t.Errorf("expected field cardinality 4500, got %d, err: %v", card, err)
+	}
+
+	fd, err := reader.FieldDict("slogan")
+	if err != nil {
+		t.Fatalf("error getting field dict: %v", err)
+	}
+	expected := map[string]uint64{"code": 40, "match": 7}
+	for entry, err := fd.Next(); entry != nil; entry, err = fd.Next() {
+		if err != nil {
+			t.Fatalf("error iterating field dict: %v", err)
+		}
+		if entry.Count != expected[entry.Term] {
+			t.Errorf("expected term '%s' count %d, got %d", entry.Term, expected[entry.Term], entry.Count)
+		}
+	}
+
+	s.SetCorpusStats(nil)
+	count, err = reader.DocCount()
+	if err != nil || count != 1 {
+		t.Errorf("expected doc count 1, got %d, err: %v", count, err)
+	}
+}
+
+func TestStreamingCorpusStats(t *testing.T) {
+	idx, err := New("", map[string]interface{}{
+		ConfigStreamingCorpusStats:       true,
+		ConfigStreamingCorpusStatsWindow: 2,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mapAndUpdateDocument(t, idx, "a", map[string]interface{}{
+		"slogan": "code match code",
+	})
+	mapAndUpdateDocument(t, idx, "b", map[string]interface{}{
+		"slogan": "code",
+		"name":   "marty",
+	})
+
+	reader, err := idx.Reader()
+	if err != nil {
+		t.Fatalf("error getting index reader: %v", err)
+	}
+	assertCorpus := func(docCount, codeCount uint64, sloganCard int) {
+		t.Helper()
+		count, err := reader.DocCount()
+		if err != nil || count != docCount {
+			t.Errorf("expected doc count %d, got %d, err: %v", docCount, count, err)
+		}
+		tfr, err := reader.TermFieldReader(nil, []byte("code"), "slogan", true, true, false)
+		if err != nil {
+			t.Fatalf("error getting term field reader: %v", err)
+		}
+		if tfr.Count() != codeCount {
+			t.Errorf("expected term count %d, got %d", codeCount, tfr.Count())
+		}
+		card, err := reader.(index.BM25Reader).FieldCardinality("slogan")
+		if err != nil || card != sloganCard {
+			t.Errorf("expected field cardinality %d, got %d, err: %v", sloganCard, card, err)
+		}
+	}
+
+	// only doc b is indexed, but both docs are in the corpus
+	assertCorpus(2, 2, 2)
+
+	// doc a leaves the window
+	mapAndUpdateDocument(t, idx, "c", map[string]interface{}{
+		"slogan": "code code code",
+	})
+	assertCorpus(2, 2, 1)
+
+	// doc b leaves the window, along with the name field
+	mapAndUpdateDocument(t, idx, "d", map[string]interface{}{
+		"slogan": "match",
+	})
+	assertCorpus(2, 0, 2)
+	tfr, err := reader.TermFieldReader(nil, []byte("match"), "slogan", true, true, false)
+	if err != nil {
+		t.Fatalf("error getting term field reader: %v", err)
+	}
+	if tfr.Count() != 1 {
+		t.Errorf("expected term count 1, got %d", tfr.Count())
+	}
+	corpus := idx.(*Sear).corpus
+	if corpus.DocFreq("name", "marty") != 0 || corpus.FieldCardinality("name") != 0 {
+		t.Errorf("expected name field to have left the window")
+	}
+
+	// without a window, every document observed is reflected
+	unlimited := NewStreamingCorpusStats(0)
+	for i := 0; i < 5; i++ {
+		unlimited.Observe(idx.(*Sear).docs[0])
+	}
+	if unlimited.DocCount() != 5 || unlimited.DocFreq("slogan", "match") != 5 ||
+		unlimited.FieldCardinality("slogan") != 1 {
+		t.Errorf("unexpected unlimited stats %d %d %d", unlimited.DocCount(),
+			unlimited.DocFreq("slogan", "match"), unlimited.FieldCardinality("slogan"))
+	}
+}
+
+var bm25TestDocs = []map[string]interface{}{
+	{"slogan": "code match code"},
+	{"slogan": "code"},
+	{"slogan": "fuzzy match scoring"},
+}
+
+// bm25AvgDocLength is the average field length bleve derives
+// for BM25 scoring, from the reader's stats
+func bm25AvgDocLength(t *testing.T, reader index.IndexReader, field string) float64 {
+	t.Helper()
+	count, err := reader.DocCount()
+	if err != nil {
+		t.Fatalf("error getting doc count: %v", err)
+	}
+	card, err := reader.(index.BM25Reader).FieldCardinality(field)
+	if err != nil {
+		t.Fatalf("error getting field cardinality: %v", err)
+	}
+	return math.Ceil(float64(card) / float64(count))
+}
+
+// newBM25Reference indexes bm25TestDocs together,
+// its stats are those scorch would report for them
+func newBM25Reference(t *testing.T) index.IndexReader {
+	ref, err := NewMulti(len(bm25TestDocs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, fields := range bm25TestDocs {
+		mapAndUpdateDocument(t, ref, string(rune('a'+i)), fields)
+	}
+	reader, err := ref.Reader()
+	if err != nil {
+		t.Fatalf("error getting index reader: %v", err)
+	}
+	// 4 unique terms in 3 documents
+	if avg := bm25AvgDocLength(t, reader, "slogan"); avg != 2 {
+		t.Fatalf("expected reference avg doc length 2, got %f", avg)
+	}
+	return reader
+}
+
+func TestCorpusStatsBM25(t *testing.T) {
+	ref := newBM25Reference(t)
+	card, err := ref.(index.BM25Reader).FieldCardinality("slogan")
+	if err != nil {
+		t.Fatalf("error getting field cardinality: %v", err)
+	}
+	count, err := ref.DocCount()
+	if err != nil {
+		t.Fatalf("error getting doc count: %v", err)
+	}
+
+	// stats exported from the reference give the same avg doc length
+	idx, err := New("", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx.(*Sear).SetCorpusStats(&StaticCorpusStats{
+		Docs:               count,
+		FieldCardinalities: map[string]int{"slogan": card},
+	})
+	mapAndUpdateDocument(t, idx, "a", bm25TestDocs[0])
+	reader, err := idx.Reader()
+	if err != nil {
+		t.Fatalf("error getting index reader: %v", err)
+	}
+	if avg := bm25AvgDocLength(t, reader, "slogan"); avg != 2 {
+		t.Errorf("expected avg doc length 2, got %f", avg)
+	}
+
+	// as do stats streamed from the same documents
+	idx, err = New("", map[string]interface{}{
+		ConfigStreamingCorpusStats: true,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, fields := range bm25TestDocs {
+		mapAndUpdateDocument(t, idx, string(rune('a'+i)), fields)
+	}
+	reader, err = idx.Reader()
+	if err != nil {
+		t.Fatalf("error getting index reader: %v", err)
+	}
+	if avg := bm25AvgDocLength(t, reader, "slogan"); avg != 2 {
+		t.Errorf("expected streamed avg doc length 2, got %f", avg)
+	}
+}
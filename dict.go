@@ -30,6 +30,10 @@ type FieldDict struct {
 	terms       []string
 	index       int
 	includeFunc func(term string) bool
+	count       func(term string) uint64
+
+	// when set, includes only matching terms, with their edit distance
+	fuzzy func(term string) (bool, uint8)
 
 	next index.DictEntry
 }
@@ -54,8 +58,19 @@ func (d *FieldDict) Next() (*index.DictEntry, error) {
 			d.index++
 			continue
 		}
+		if d.fuzzy != nil {
+			var match bool
+			match, d.next.EditDistance = d.fuzzy(d.terms[d.index])
+			if !match {
+				d.index++
+				continue
+			}
+		}
 		d.next.Term = d.terms[d.index]
 		d.next.Count = 1
+		if d.count != nil {
+			d.next.Count = d.count(d.next.Term)
+		}
 
 		d.index++
 		return &d.next, nil
@@ -77,7 +92,9 @@ func (d *FieldDict) Close() error {
 }
 
 type FieldDictContains struct {
-	atf index.TokenFrequencies
+	atfs []index.TokenFrequencies
+
+	one [1]index.TokenFrequencies
 }
 
 var fieldDictContainsEmpty = NewFieldDictContainsEmpty()
@@ -87,17 +104,17 @@ func NewFieldDictContainsEmpty() *FieldDictContains {
 }
 
 func NewFieldDictContainsFromTokenFrequencies(atf index.TokenFrequencies) *FieldDictContains {
-	return &FieldDictContains{
-		atf: atf,
-	}
+	rv := &FieldDictContains{}
+	rv.one[0] = atf
+	rv.atfs = rv.one[:]
+	return rv
 }
 
 func (d *FieldDictContains) Contains(key []byte) (bool, error) {
-	if d.atf == nil {
-		return false, nil
-	}
-	if _, ok := d.atf[string(key)]; ok {
-		return true, nil
+	for _, atf := range d.atfs {
+		if _, ok := atf[string(key)]; ok {
+			return true, nil
+		}
 	}
 	return false, nil
 }
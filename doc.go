@@ -17,6 +17,7 @@ package sear
 import (
 	"fmt"
 	"sort"
+	"sync/atomic"
 
 	index "github.com/blevesearch/bleve_index_api"
 )
@@ -29,10 +30,39 @@ type Document struct {
 	fieldNames      []string
 	fieldTokenFreqs []index.TokenFrequencies
 	fieldLens       []int
-	vectorDims      []int // applicable to vector fields only
+	vectors         []fieldVector // applicable to vector fields only
 
 	// deferred build and cache
 	sortedTerms map[string][]string
+
+	// number of open snapshot readers using this document,
+	// which may be closed on other goroutines
+	snapshots atomic.Int32
+
+	stored storedDocument
+}
+
+// fieldVector is the vector indexed for a field, it references
+// the field's own values, which are not modified.  A field with
+// multiple vectors has them all, each of dims values, in vector.
+type fieldVector struct {
+	dims       int
+	similarity string
+	vector     []float32
+}
+
+// merge appends the vectors of another occurrence of the field
+func (v *fieldVector) merge(other fieldVector) {
+	if other.dims == 0 {
+		return
+	}
+	if v.dims == 0 {
+		*v = other
+		return
+	}
+	// force a copy, rather than append to the first field's values
+	n := len(v.vector)
+	v.vector = append(v.vector[:n:n], other.vector...)
 }
 
 func NewDocument() *Document {
@@ -66,10 +96,11 @@ func (d *Document) newField(field index.Field) {
 		d.fieldNames = append(d.fieldNames, field.Name())
 		d.fieldTokenFreqs = append(d.fieldTokenFreqs, af)
 		d.fieldLens = append(d.fieldLens, field.AnalyzedLength())
-		d.vectorDims = append(d.vectorDims, d.interpretVectorIfApplicable(field))
+		d.vectors = append(d.vectors, d.interpretVectorIfApplicable(field))
 	} else {
 		d.fieldTokenFreqs[fieldIdx].MergeAll(field.Name(), af)
 		d.fieldLens[fieldIdx] += field.AnalyzedLength()
+		d.vectors[fieldIdx].merge(d.interpretVectorIfApplicable(field))
 	}
 }
 
@@ -102,7 +133,7 @@ func (d *Document) Reset(doc index.Document) {
 	d.fieldNames = d.fieldNames[:0]
 	d.fieldTokenFreqs = d.fieldTokenFreqs[:0]
 	d.fieldLens = d.fieldLens[:0]
-	d.vectorDims = d.vectorDims[:0]
+	d.vectors = d.vectors[:0]
 
 	// clear cache
 	for k := range d.sortedTerms {
@@ -111,9 +142,16 @@ func (d *Document) Reset(doc index.Document) {
 
 	// init new doc
 	d.doc = doc
+	d.stored.doc = doc
 	d.analyze()
 }
 
+// StoredDocument returns a view of the indexed document
+// which only visits the fields which were stored.
+func (d *Document) StoredDocument() index.Document {
+	return &d.stored
+}
+
 func (d *Document) Fields() []string {
 	return d.fieldNames
 }
@@ -129,13 +167,21 @@ func (d *Document) SortedTermsForField(fieldName string) ([]string, error) {
 		return terms, nil
 	}
 
+	terms = d.appendSortedTerms(terms, fieldIdx)
+	d.sortedTerms[fieldName] = terms
+	return terms, nil
+}
+
+// appendSortedTerms appends the sorted terms of the field to
+// terms, without using the cache, for snapshot readers which
+// may run concurrently with the index using the document
+func (d *Document) appendSortedTerms(terms []string, fieldIdx int) []string {
 	atf := d.fieldTokenFreqs[fieldIdx]
 	for k := range atf {
 		terms = append(terms, k)
 	}
 	sort.Strings(terms)
-	d.sortedTerms[fieldName] = terms
-	return terms, nil
+	return terms
 }
 
 func (d *Document) TokenFreqsAndLen(fieldName string) (index.TokenFrequencies, int, error) {
@@ -152,5 +198,50 @@ func (d *Document) VectorDims(fieldName string) (dims int, err error) {
 		return 0, err
 	}
 
-	return d.vectorDims[fieldIdx], nil
+	return d.vectors[fieldIdx].dims, nil
+}
+
+// storedDocument wraps an indexed document, exposing only
+// its stored fields, as they would be returned by an index
+// which persists stored fields.
+type storedDocument struct {
+	doc index.Document
+}
+
+func (s *storedDocument) ID() string {
+	return s.doc.ID()
+}
+
+func (s *storedDocument) Size() int {
+	return s.doc.Size()
+}
+
+func (s *storedDocument) VisitFields(visitor index.FieldVisitor) {
+	s.doc.VisitFields(func(field index.Field) {
+		if field.Options().IsStored() {
+			visitor(field)
+		}
+	})
+}
+
+func (s *storedDocument) VisitComposite(visitor index.CompositeFieldVisitor) {
+	// composite fields are never stored
+}
+
+func (s *storedDocument) HasComposite() bool {
+	return false
+}
+
+func (s *storedDocument) NumPlainTextBytes() uint64 {
+	return s.doc.NumPlainTextBytes()
+}
+
+func (s *storedDocument) AddIDField() {}
+
+func (s *storedDocument) StoredFieldsBytes() uint64 {
+	return s.doc.StoredFieldsBytes()
+}
+
+func (s *storedDocument) Indexed() bool {
+	return s.doc.Indexed()
 }
@@ -20,38 +20,68 @@ import (
 	index "github.com/blevesearch/bleve_index_api"
 )
 
+// internalDocIDs holds the internal id for each doc number,
+// they are a single byte, so that they sort in doc number order
+var internalDocIDs = func() []index.IndexInternalID {
+	rv := make([]index.IndexInternalID, MaxDocs)
+	for i := range rv {
+		rv[i] = index.IndexInternalID{byte(i)}
+	}
+	return rv
+}()
+
+// internalDocID is the internal id of the first (or only) document
+var internalDocID = internalDocIDs[0]
+
+// docNumForInternalID returns the doc number for the
+// provided internal id, and whether it is valid
+func docNumForInternalID(id index.IndexInternalID) (int, bool) {
+	if len(id) != 1 {
+		return 0, false
+	}
+	return int(id[0]), true
+}
+
 type DocIDReader struct {
-	done bool
+	ids  []index.IndexInternalID
+	next int
 }
 
 var docIDReaderEmpty = NewDocIDReaderEmpty()
 
 func NewDocIDReaderEmpty() *DocIDReader {
-	return &DocIDReader{
-		done: true,
-	}
+	return &DocIDReader{}
 }
 
 func NewDocIDReader() *DocIDReader {
-	return &DocIDReader{}
+	return NewDocIDReaderWithIDs(internalDocIDs[:1])
+}
+
+// NewDocIDReaderWithIDs returns a reader which iterates the
+// provided internal ids, which must already be sorted.
+func NewDocIDReaderWithIDs(ids []index.IndexInternalID) *DocIDReader {
+	return &DocIDReader{
+		ids: ids,
+	}
 }
 
 func (d *DocIDReader) Next() (index.IndexInternalID, error) {
-	if d.done {
+	if d.next >= len(d.ids) {
 		return nil, nil
 	}
-	d.done = true
-	return internalDocID, nil
+	rv := d.ids[d.next]
+	d.next++
+	return rv, nil
 }
 
+// Advance resets the enumeration at specified document or its immediate
+// follower.
 func (d *DocIDReader) Advance(id index.IndexInternalID) (index.IndexInternalID, error) {
-	if d.done {
-		return nil, nil
-	}
-	if bytes.Compare(id, internalDocID) > 0 {
-		// seek is after our internal id
-		d.done = true
-		return nil, nil
+	// seek from the start so backwards seeks are honoured
+	d.next = 0
+	for d.next < len(d.ids) && bytes.Compare(d.ids[d.next], id) < 0 {
+		// seek is after this internal id
+		d.next++
 	}
 	return d.Next()
 }
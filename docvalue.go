@@ -15,7 +15,6 @@
 package sear
 
 import (
-	"bytes"
 	"fmt"
 
 	index "github.com/blevesearch/bleve_index_api"
@@ -27,15 +26,17 @@ type DocValueReader struct {
 }
 
 func (d *DocValueReader) VisitDocValues(id index.IndexInternalID, visitor index.DocValueVisitor) error {
-	if d.r.s.doc == nil {
+	if len(d.r.ds.docs) == 0 {
 		return nil
 	}
-	if !bytes.Equal(id, internalDocID) {
+	num, ok := docNumForInternalID(id)
+	if !ok || num >= len(d.r.ds.docs) {
 		return fmt.Errorf("unknown doc id: '%v", id)
 	}
+	doc := d.r.ds.docs[num]
 
 	for _, dvrField := range d.fields {
-		atf, _, err := d.r.s.doc.TokenFreqsAndLen(dvrField)
+		atf, _, err := doc.TokenFreqsAndLen(dvrField)
 		if err == nil {
 			for _, v := range atf {
 				visitor(dvrField, v.Term)
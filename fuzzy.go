@@ -14,9 +14,48 @@
 
 package sear
 
+import (
+	"unicode/utf8"
+)
+
+// maxAutomatonFuzziness is the largest fuzziness
+// for which vellum Levenshtein automata are built
+const maxAutomatonFuzziness = 2
+
+// levenshteinMatcher is the FuzzyAutomaton used for fuzziness
+// beyond maxAutomatonFuzziness, computing distances directly.
+// It is not safe for concurrent use, reusing its slice.
+type levenshteinMatcher struct {
+	term      string
+	fuzziness int
+	d         []int
+}
+
+func newLevenshteinMatcher(term string, fuzziness int) *levenshteinMatcher {
+	return &levenshteinMatcher{
+		term:      term,
+		fuzziness: fuzziness,
+	}
+}
+
+func (l *levenshteinMatcher) MatchAndDistance(term string) (bool, uint8) {
+	var dist int
+	var exceeded bool
+	dist, exceeded, l.d = levenshteinDistanceMaxReuseSlice(l.term, term, l.fuzziness, l.d)
+	if exceeded || dist > l.fuzziness {
+		return false, 0
+	}
+	return true, uint8(dist)
+}
+
+// levenshteinDistanceMaxReuseSlice returns the edit distance between a
+// and b in runes, or exceeded if it is greater than max.  Like the vellum
+// Levenshtein automaton, transposing adjacent runes is a single edit
+// (the optimal string alignment distance).  The slice d is reused
+// between calls.
 func levenshteinDistanceMaxReuseSlice(a, b string, max int, d []int) (dist int, exceeded bool, reuse []int) {
-	la := len(a)
-	lb := len(b)
+	la := utf8.RuneCountInString(a)
+	lb := utf8.RuneCountInString(b)
 
 	ld := la - lb
 	if ld < 0 {
@@ -26,45 +65,54 @@ func levenshteinDistanceMaxReuseSlice(a, b string, max int, d []int) (dist int,
 		return max, true, d
 	}
 
-	if cap(d) < la+1 {
-		d = make([]int, la+1)
+	n := la + 1
+	if cap(d) < 3*n {
+		d = make([]int, 3*n)
 	}
-	d = d[:la+1]
-
-	var lastdiag, olddiag, temp int
+	d = d[:3*n]
 
-	for i := 1; i <= la; i++ {
-		d[i] = i
+	// rows i-2, i-1 and i of the distance matrix
+	prev2, prev, cur := d[:n], d[n:2*n], d[2*n:]
+	for j := range prev {
+		prev[j] = j
 	}
-	for i := 1; i <= lb; i++ {
-		d[0] = i
-		lastdiag = i - 1
-		rowmin := max + 1
-		for j := 1; j <= la; j++ {
-			olddiag = d[j]
-			min := d[j] + 1
-			if (d[j-1] + 1) < min {
-				min = d[j-1] + 1
+
+	var prevRb rune
+	i := 0
+	for _, rb := range b {
+		i++
+		cur[0] = i
+		rowmin := i // cur[0]
+		var prevRa rune
+		j := 0
+		for _, ra := range a {
+			j++
+			min := prev[j-1] // substitution
+			if ra != rb {
+				min++
+			}
+			if prev[j]+1 < min {
+				min = prev[j] + 1
 			}
-			if a[j-1] == b[i-1] {
-				temp = 0
-			} else {
-				temp = 1
+			if cur[j-1]+1 < min {
+				min = cur[j-1] + 1
 			}
-			if (lastdiag + temp) < min {
-				min = lastdiag + temp
+			if i > 1 && j > 1 && ra == prevRb && prevRa == rb && prev2[j-2]+1 < min {
+				min = prev2[j-2] + 1
 			}
 			if min < rowmin {
 				rowmin = min
 			}
-			d[j] = min
-
-			lastdiag = olddiag
+			cur[j] = min
+			prevRa = ra
 		}
-		// after each row if rowmin isn't less than max stop
+		// after each row if rowmin isn't less than max stop, a
+		// transposition cannot lower the next row below this one
 		if rowmin > max {
 			return max, true, d
 		}
+		prev2, prev, cur = prev, cur, prev2
+		prevRb = rb
 	}
-	return d[la], false, d
+	return prev[la], false, d
 }
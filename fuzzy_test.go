@@ -17,6 +17,9 @@ package sear
 import (
 	"fmt"
 	"testing"
+
+	index "github.com/blevesearch/bleve_index_api"
+	"github.com/blevesearch/vellum"
 )
 
 func TestFuzzyMatch(t *testing.T) {
@@ -64,6 +67,45 @@ func TestFuzzyMatch(t *testing.T) {
 				"tacs",
 			},
 		},
+		{
+			searchTerm: "über",
+			fuzziness:  1,
+			shouldMatch: []string{
+				"uber",
+				"übe",
+				"übers",
+				"öber",
+			},
+			shouldNotMatch: []string{
+				"ub",
+				"obere",
+			},
+		},
+		{
+			searchTerm: "東京",
+			fuzziness:  1,
+			shouldMatch: []string{
+				"東京都",
+				"京",
+				"東北",
+			},
+			shouldNotMatch: []string{
+				"北京都",
+				"大阪",
+			},
+		},
+		{
+			searchTerm: "",
+			fuzziness:  1,
+			shouldMatch: []string{
+				"",
+				"a",
+				"é",
+			},
+			shouldNotMatch: []string{
+				"ab",
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -84,3 +126,284 @@ func TestFuzzyMatch(t *testing.T) {
 		})
 	}
 }
+
+// TestFieldDictFuzzyPrefix checks the scorch semantics of the prefix,
+// which is the first prefix_length runes of the term: candidates must
+// start with the prefix, and be within fuzziness of the whole term.
+func TestFieldDictFuzzyPrefix(t *testing.T) {
+	idx, err := New("", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mapAndUpdateDocument(t, idx, "a", map[string]interface{}{
+		"body": "marty martin mary marry party über überall",
+	})
+	reader, err := idx.Reader()
+	if err != nil {
+		t.Fatalf("error getting index reader: %v", err)
+	}
+	readerFuzzy := reader.(index.IndexReaderFuzzy)
+
+	tests := []struct {
+		term      string
+		fuzziness int
+		prefix    string
+		expected  []string
+	}{
+		{"marty", 1, "", []string{"marry", "marty", "mary", "party"}},
+		{"marty", 1, "m", []string{"marry", "marty", "mary"}},
+		{"marty", 1, "mart", []string{"marty"}},
+		{"marty", 2, "mar", []string{"marry", "martin", "marty", "mary"}},
+		{"marty", 1, "marty", []string{"marty"}},
+		{"marty", 2, "x", nil},
+		{"parti", 1, "p", []string{"party"}},
+		{"über", 1, "ü", []string{"über"}},
+		{"überal", 1, "übe", []string{"überall"}},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%s-%d-%s", test.term, test.fuzziness, test.prefix), func(t *testing.T) {
+			fd, err := readerFuzzy.FieldDictFuzzy("body", test.term, test.fuzziness, test.prefix)
+			if err != nil {
+				t.Fatalf("error getting field dict fuzzy: %v", err)
+			}
+			assertTermDictionary(t, fd, test.expected)
+
+			fd, _, err = readerFuzzy.FieldDictFuzzyAutomaton("body", test.term, test.fuzziness, test.prefix)
+			if err != nil {
+				t.Fatalf("error getting field dict fuzzy automaton: %v", err)
+			}
+			assertTermDictionary(t, fd, test.expected)
+		})
+	}
+}
+
+// TestFieldDictFuzzyAutomatonAgrees checks that the dictionary returned
+// agrees with the automaton returned, as both are used by bleve
+func TestFieldDictFuzzyAutomatonAgrees(t *testing.T) {
+	idx, err := New("", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mapAndUpdateDocument(t, idx, "a", map[string]interface{}{
+		"body": "über uber ubers öber obere café cafe caffè 東京 東京都 北京 naïve naive",
+	})
+	reader, err := idx.Reader()
+	if err != nil {
+		t.Fatalf("error getting index reader: %v", err)
+	}
+	readerFuzzy := reader.(index.IndexReaderFuzzy)
+	terms, err := idx.(*Sear).SortedTermsForField("body")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, term := range []string{"über", "cafe", "café", "東京", "naive", "ober"} {
+		for fuzziness := 1; fuzziness <= 2; fuzziness++ {
+			fd, a, err := readerFuzzy.FieldDictFuzzyAutomaton("body", term, fuzziness, "")
+			if err != nil {
+				t.Fatalf("error getting field dict fuzzy automaton: %v", err)
+			}
+			var expected []string
+			for _, indexTerm := range terms {
+				if match, _ := a.MatchAndDistance(indexTerm); match {
+					expected = append(expected, indexTerm)
+				}
+			}
+			if len(expected) == 0 {
+				t.Fatalf("expected automaton for %s-%d to match some terms", term, fuzziness)
+			}
+			assertTermDictionary(t, fd, expected)
+		}
+	}
+}
+
+func TestFieldDictFuzzyBeyondAutomaton(t *testing.T) {
+	idx, err := New("", map[string]interface{}{
+		ConfigMaxFuzziness: 3,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mapAndUpdateDocument(t, idx, "a", map[string]interface{}{
+		"body": "alert alerts alarm alarms aleph bert",
+	})
+	reader, err := idx.Reader()
+	if err != nil {
+		t.Fatalf("error getting index reader: %v", err)
+	}
+	readerFuzzy := reader.(index.IndexReaderFuzzy)
+
+	fd, err := readerFuzzy.FieldDictFuzzy("body", "alert", 3, "")
+	if err != nil {
+		t.Fatalf("error getting field dict fuzzy: %v", err)
+	}
+	expected := []string{"alarm", "alarms", "aleph", "alert", "alerts", "bert"}
+	assertTermDictionary(t, fd, expected)
+
+	fd, a, err := readerFuzzy.FieldDictFuzzyAutomaton("body", "alert", 3, "al")
+	if err != nil {
+		t.Fatalf("error getting field dict fuzzy automaton: %v", err)
+	}
+	assertTermDictionary(t, fd, []string{"alarm", "alarms", "aleph", "alert", "alerts"})
+	for term, distance := range map[string]uint8{"alert": 0, "alerts": 1, "aleph": 2, "alarm": 2, "alarms": 3, "bert": 2} {
+		match, dist := a.MatchAndDistance(term)
+		if !match || dist != distance {
+			t.Errorf("expected %s to match at distance %d, got %t %d", term, distance, match, dist)
+		}
+	}
+	if match, _ := a.MatchAndDistance("xyz"); match {
+		t.Errorf("expected xyz not to match")
+	}
+
+	_, err = readerFuzzy.FieldDictFuzzy("body", "alert", 4, "")
+	if err == nil {
+		t.Errorf("expected error for fuzziness exceeding max")
+	}
+}
+
+func TestLevenshteinCache(t *testing.T) {
+	idx, err := New("", map[string]interface{}{
+		ConfigLevenshteinCacheSize: 2,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := idx.(*Sear)
+	reader, err := idx.Reader()
+	if err != nil {
+		t.Fatalf("error getting index reader: %v", err)
+	}
+	readerFuzzy := reader.(index.IndexReaderFuzzy)
+
+	// the automaton is reused for subsequent documents
+	var first index.FuzzyAutomaton
+	for _, name := range []string{"gas", "gap", "steve"} {
+		mapAndUpdateDocument(t, idx, "a", map[string]interface{}{
+			"name": name,
+		})
+		_, a, err := readerFuzzy.FieldDictFuzzyAutomaton("name", "gas", 1, "")
+		if err != nil {
+			t.Fatalf("error getting field dict fuzzy automaton: %v", err)
+		}
+		if first == nil {
+			first = a
+		} else if a != first {
+			t.Errorf("expected cached automaton to be reused")
+		}
+	}
+	stats := s.Stats()
+	if stats.TotLevCacheMisses != 1 || stats.TotLevCacheHits != 2 {
+		t.Errorf("expected 1 miss and 2 hits, got %d and %d",
+			stats.TotLevCacheMisses, stats.TotLevCacheHits)
+	}
+
+	// the cache is bounded
+	for _, term := range []string{"gas", "gap", "gaz"} {
+		for fuzziness := 1; fuzziness <= 2; fuzziness++ {
+			_, _, err = readerFuzzy.FieldDictFuzzyAutomaton("name", term, fuzziness, "")
+			if err != nil {
+				t.Fatalf("error getting field dict fuzzy automaton: %v", err)
+			}
+			if s.reader.levCache.Len() > 2 {
+				t.Errorf("expected at most 2 cached automata, got %d", s.reader.levCache.Len())
+			}
+		}
+	}
+}
+
+func TestFieldDictFuzzyEditDistance(t *testing.T) {
+	idx, err := New("", map[string]interface{}{
+		ConfigMaxFuzziness: 3,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mapAndUpdateDocument(t, idx, "a", map[string]interface{}{
+		"body": "gas gsa gap gaps grasp über",
+	})
+	reader, err := idx.Reader()
+	if err != nil {
+		t.Fatalf("error getting index reader: %v", err)
+	}
+	readerFuzzy := reader.(index.IndexReaderFuzzy)
+
+	tests := []struct {
+		term      string
+		fuzziness int
+		expected  map[string]uint8
+	}{
+		// transpositions count as one edit, like scorch,
+		// including beyond the fuzziness of the automata
+		{"gas", 1, map[string]uint8{"gap": 1, "gaps": 1, "gas": 0, "gsa": 1}},
+		{"gas", 2, map[string]uint8{"gap": 1, "gaps": 1, "gas": 0, "grasp": 2, "gsa": 1}},
+		{"uber", 1, map[string]uint8{"über": 1}},
+		{"gas", 3, map[string]uint8{"gap": 1, "gaps": 1, "gas": 0, "grasp": 2, "gsa": 1}},
+	}
+
+	for _, test := range tests {
+		fd, err := readerFuzzy.FieldDictFuzzy("body", test.term, test.fuzziness, "")
+		if err != nil {
+			t.Fatalf("error getting field dict fuzzy: %v", err)
+		}
+		seen := map[string]uint8{}
+		for entry, err := fd.Next(); entry != nil; entry, err = fd.Next() {
+			if err != nil {
+				t.Fatalf("error iterating field dict: %v", err)
+			}
+			seen[entry.Term] = entry.EditDistance
+		}
+		if fmt.Sprint(seen) != fmt.Sprint(test.expected) {
+			t.Errorf("%s-%d expected %v, got %v", test.term, test.fuzziness, test.expected, seen)
+		}
+	}
+}
+
+func TestLevenshteinMatcherTranspositions(t *testing.T) {
+	tests := []struct {
+		a, b      string
+		fuzziness int
+		distance  int
+		match     bool
+	}{
+		{"ab", "ba", 3, 1, true},
+		{"abcdef", "badcfe", 3, 3, true},
+		{"abcdef", "badcfe", 2, 0, false},
+		{"über", "üebr", 3, 1, true},
+		// optimal string alignment, a transposed pair is not edited again
+		{"ca", "abc", 3, 3, true},
+	}
+	for _, test := range tests {
+		match, dist := newLevenshteinMatcher(test.a, test.fuzziness).MatchAndDistance(test.b)
+		if match != test.match || int(dist) != test.distance {
+			t.Errorf("%s-%s-%d expected %t %d, got %t %d",
+				test.a, test.b, test.fuzziness, test.match, test.distance, match, dist)
+		}
+	}
+}
+
+// TestLevenshteinMatcherAgreesWithAutomaton checks that the distances
+// computed beyond the fuzziness of the automata are the same as those
+// of the automata, where both apply
+func TestLevenshteinMatcherAgreesWithAutomaton(t *testing.T) {
+	words := []string{"", "a", "ab", "ba", "abc", "acb", "bca", "cab", "abcd", "badc",
+		"abdc", "dcba", "aabb", "abab", "über", "üebr", "ubër", "東京", "京東"}
+	for _, term := range words {
+		for fuzziness := 1; fuzziness <= maxAutomatonFuzziness; fuzziness++ {
+			a, err := getLevAutomaton(term, uint8(fuzziness))
+			if err != nil {
+				t.Fatalf("error building automaton: %v", err)
+			}
+			fa := a.(vellum.FuzzyAutomaton)
+			lm := newLevenshteinMatcher(term, fuzziness)
+			for _, word := range words {
+				match, dist := fa.MatchAndDistance(word)
+				lmMatch, lmDist := lm.MatchAndDistance(word)
+				if match != lmMatch || dist != lmDist {
+					t.Errorf("%s-%d %s expected %t %d, got %t %d",
+						term, fuzziness, word, match, dist, lmMatch, lmDist)
+				}
+			}
+		}
+	}
+}
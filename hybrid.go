@@ -0,0 +1,152 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//              http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sear
+
+import (
+	"context"
+	"fmt"
+
+	index "github.com/blevesearch/bleve_index_api"
+)
+
+// HybridScores are the scores of the current document
+// for the components of a Hybrid query, and their fusion.
+type HybridScores struct {
+	TextMatched   bool
+	TextScore     float64
+	VectorMatched bool
+	VectorScore   float64
+
+	// Matched if either component matched
+	Matched bool
+	Score   float64
+}
+
+// Fusion combines the component scores of a Hybrid query.
+type Fusion interface {
+	Fuse(scores *HybridScores) float64
+}
+
+// LinearFusion is the weighted sum of the component scores,
+// components which did not match contribute nothing.
+type LinearFusion struct {
+	TextWeight   float64
+	VectorWeight float64
+}
+
+// NewLinearFusion returns a LinearFusion with weights of 1,
+// a zero weight ignores that component.
+func NewLinearFusion() *LinearFusion {
+	return &LinearFusion{
+		TextWeight:   1,
+		VectorWeight: 1,
+	}
+}
+
+func (f *LinearFusion) Fuse(scores *HybridScores) float64 {
+	var rv float64
+	if scores.TextMatched {
+		rv += f.TextWeight * scores.TextScore
+	}
+	if scores.VectorMatched {
+		rv += f.VectorWeight * scores.VectorScore
+	}
+	return rv
+}
+
+// DefaultRankConstant is the RRF rank constant used by bleve.
+const DefaultRankConstant = 60
+
+// RRFFusion is reciprocal rank fusion, as for a single document,
+// each component which matched ranks it first, contributing
+// weight / (RankConstant + 1).  RankConstant must not be negative.
+type RRFFusion struct {
+	RankConstant int
+	TextWeight   float64
+	VectorWeight float64
+}
+
+// NewRRFFusion returns an RRFFusion with the DefaultRankConstant
+// and weights of 1, a zero weight ignores that component.
+func NewRRFFusion() *RRFFusion {
+	return &RRFFusion{
+		RankConstant: DefaultRankConstant,
+		TextWeight:   1,
+		VectorWeight: 1,
+	}
+}
+
+func (f *RRFFusion) Fuse(scores *HybridScores) float64 {
+	var rv float64
+	if scores.TextMatched {
+		rv += f.TextWeight / float64(f.RankConstant+1)
+	}
+	if scores.VectorMatched {
+		rv += f.VectorWeight / float64(f.RankConstant+1)
+	}
+	return rv
+}
+
+// Hybrid combines a text query and a vector (kNN) query, and
+// reports the scores of each for the current document, along
+// with their fusion.  It is itself a Query, matching if either
+// component matches, with the fused score, so can be used with
+// a Matcher, a MatcherPool or a Percolator.
+type Hybrid struct {
+	text   Query
+	vector Query
+	fusion Fusion
+}
+
+// NewHybrid returns a Hybrid of the text and vector queries,
+// combining their scores using fusion.
+func NewHybrid(text, vector Query, fusion Fusion) (*Hybrid, error) {
+	if text == nil || vector == nil {
+		return nil, fmt.Errorf("hybrid requires both a text and a vector query")
+	}
+	if fusion == nil {
+		return nil, fmt.Errorf("hybrid requires a fusion")
+	}
+	return &Hybrid{
+		text:   text,
+		vector: vector,
+		fusion: fusion,
+	}, nil
+}
+
+// Score executes both queries against the reader.
+func (h *Hybrid) Score(ctx context.Context, r index.IndexReader) (HybridScores, error) {
+	var rv HybridScores
+	var err error
+	rv.TextMatched, rv.TextScore, err = h.text.Match(ctx, r)
+	if err != nil {
+		return rv, fmt.Errorf("error executing text query: %v", err)
+	}
+	rv.VectorMatched, rv.VectorScore, err = h.vector.Match(ctx, r)
+	if err != nil {
+		return rv, fmt.Errorf("error executing vector query: %v", err)
+	}
+	rv.Matched = rv.TextMatched || rv.VectorMatched
+	if rv.Matched {
+		rv.Score = h.fusion.Fuse(&rv)
+	}
+	return rv, nil
+}
+
+// Match implements Query.
+func (h *Hybrid) Match(ctx context.Context, r index.IndexReader) (bool, float64, error) {
+	scores, err := h.Score(ctx, r)
+	return scores.Matched, scores.Score, err
+}
@@ -0,0 +1,172 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//              http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sear
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+
+	index "github.com/blevesearch/bleve_index_api"
+)
+
+// testScoreQuery always returns the same outcome
+func testScoreQuery(matched bool, score float64) Query {
+	return QueryFunc(func(ctx context.Context, r index.IndexReader) (bool, float64, error) {
+		return matched, score, nil
+	})
+}
+
+func TestHybrid(t *testing.T) {
+	idx, err := New("", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mapAndUpdateDocument(t, idx, "a", map[string]interface{}{
+		"name": "marty",
+	})
+	reader, err := idx.Reader()
+	if err != nil {
+		t.Fatalf("error getting index reader: %v", err)
+	}
+	text := &testTermQuery{field: "name", term: "marty"}
+	textMiss := &testTermQuery{field: "name", term: "steve"}
+
+	tests := []struct {
+		text     Query
+		vector   Query
+		fusion   Fusion
+		expected HybridScores
+	}{
+		{
+			text:   text,
+			vector: testScoreQuery(true, 0.5),
+			fusion: &LinearFusion{TextWeight: 0.3, VectorWeight: 0.7},
+			expected: HybridScores{
+				TextMatched: true, TextScore: 1,
+				VectorMatched: true, VectorScore: 0.5,
+				Matched: true, Score: 0.65,
+			},
+		},
+		{
+			text:   textMiss,
+			vector: testScoreQuery(true, 0.5),
+			fusion: &LinearFusion{TextWeight: 0.3, VectorWeight: 0.7},
+			expected: HybridScores{
+				VectorMatched: true, VectorScore: 0.5,
+				Matched: true, Score: 0.35,
+			},
+		},
+		{
+			text:   text,
+			vector: testScoreQuery(true, 0.5),
+			fusion: NewLinearFusion(),
+			expected: HybridScores{
+				TextMatched: true, TextScore: 1,
+				VectorMatched: true, VectorScore: 0.5,
+				Matched: true, Score: 1.5,
+			},
+		},
+		{
+			// a zero weight ignores the component
+			text:   text,
+			vector: testScoreQuery(true, 0.5),
+			fusion: &LinearFusion{TextWeight: 2},
+			expected: HybridScores{
+				TextMatched: true, TextScore: 1,
+				VectorMatched: true, VectorScore: 0.5,
+				Matched: true, Score: 2,
+			},
+		},
+		{
+			text:   text,
+			vector: testScoreQuery(false, 0),
+			fusion: NewRRFFusion(),
+			expected: HybridScores{
+				TextMatched: true, TextScore: 1,
+				Matched: true, Score: 1.0 / 61,
+			},
+		},
+		{
+			text:   text,
+			vector: testScoreQuery(true, 0.5),
+			fusion: &RRFFusion{RankConstant: 1, VectorWeight: 2},
+			expected: HybridScores{
+				TextMatched: true, TextScore: 1,
+				VectorMatched: true, VectorScore: 0.5,
+				Matched: true, Score: 1,
+			},
+		},
+		{
+			text:   text,
+			vector: testScoreQuery(true, 0.5),
+			fusion: &RRFFusion{TextWeight: 1, VectorWeight: 1},
+			expected: HybridScores{
+				TextMatched: true, TextScore: 1,
+				VectorMatched: true, VectorScore: 0.5,
+				Matched: true, Score: 2,
+			},
+		},
+		{
+			text:     textMiss,
+			vector:   testScoreQuery(false, 0),
+			fusion:   NewRRFFusion(),
+			expected: HybridScores{},
+		},
+	}
+
+	for i, test := range tests {
+		h, err := NewHybrid(test.text, test.vector, test.fusion)
+		if err != nil {
+			t.Fatal(err)
+		}
+		scores, err := h.Score(context.Background(), reader)
+		if err != nil {
+			t.Fatalf("error scoring hybrid %d: %v", i, err)
+		}
+		if math.Abs(scores.Score-test.expected.Score) < 1e-9 {
+			scores.Score = test.expected.Score
+		}
+		if scores != test.expected {
+			t.Errorf("test %d expected %+v, got %+v", i, test.expected, scores)
+		}
+		matched, score, err := h.Match(context.Background(), reader)
+		if err != nil || matched != scores.Matched || math.Abs(score-scores.Score) > 1e-9 {
+			t.Errorf("test %d expected match %t %f, got %t %f, err: %v",
+				i, scores.Matched, scores.Score, matched, score, err)
+		}
+	}
+
+	_, err = NewHybrid(text, nil, NewRRFFusion())
+	if err == nil {
+		t.Errorf("expected error without vector query")
+	}
+	_, err = NewHybrid(text, text, nil)
+	if err == nil {
+		t.Errorf("expected error without fusion")
+	}
+
+	h, err := NewHybrid(text, QueryFunc(func(ctx context.Context, r index.IndexReader) (bool, float64, error) {
+		return false, 0, fmt.Errorf("failed")
+	}), NewRRFFusion())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = h.Score(context.Background(), reader)
+	if err == nil {
+		t.Errorf("expected vector query error")
+	}
+}
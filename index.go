@@ -16,19 +16,51 @@ package sear
 
 import (
 	"fmt"
+	"sort"
+	"sync"
+	"time"
 
 	index "github.com/blevesearch/bleve_index_api"
 )
 
 const Name = "sear"
 
-// Sear implements an index containing a single document.
+// MaxDocs is the largest number of documents which can be
+// held by a multi-document Sear index.
+const MaxDocs = 256
+
+// Sear implements an index containing a single document,
+// or in multi-document mode, a small bounded group of documents.
 type Sear struct {
-	doc *Document
+	docSet
+
+	// previously indexed documents, available for reuse
+	spare []*Document
+
+	config        config
+	analysisQueue *index.AnalysisQueue
 
 	internal map[string][]byte
-	stats    map[string]interface{}
+	stats    *stats
+	corpus   CorpusStats
 	reader   *Reader
+
+	// used by snapshot readers when no AutomatonCache is
+	// configured, created on first use
+	automata *AutomatonCache
+}
+
+// docSet is a group of indexed documents,
+// position is the internal doc number
+type docSet struct {
+	docs []*Document
+
+	// deferred build and cache, multi-document mode only
+	sortedTerms map[string][]string
+
+	// snapshot docSets do not use the caches of their documents,
+	// which the index may be using concurrently
+	isolated bool
 }
 
 // New creates a new instance of a Sear index.
@@ -37,16 +69,62 @@ type Sear struct {
 //
 // For example, in your application init()
 // registry.RegisterIndexType(search.Name, sear.New)
+//
+// The config keys understood are documented with the
+// Config constants, invalid values return an error.
+// If an analysisQueue is provided, it is used to analyze
+// the documents in a batch concurrently.
 func New(storeName string,
 	config map[string]interface{},
 	analysisQueue *index.AnalysisQueue) (index.Index, error) {
+	c, err := parseConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	rv := newSear(c)
+	rv.analysisQueue = analysisQueue
+	return rv, nil
+}
+
+// NewMulti creates a new instance of a Sear index which
+// holds up to maxDocs documents at once, at least 2.
+// Documents are assigned sequential internal ids in the
+// order they are indexed.
+func NewMulti(maxDocs int) (*Sear, error) {
+	if maxDocs < 2 {
+		return nil, fmt.Errorf("multi-document mode requires max docs of at least 2, got %d", maxDocs)
+	}
+	idx, err := New(Name, map[string]interface{}{
+		ConfigMaxDocs: maxDocs,
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return idx.(*Sear), nil
+}
+
+func newSear(c config) *Sear {
 	rv := &Sear{
+		docSet: docSet{
+			docs:        make([]*Document, 0, c.maxDocs),
+			sortedTerms: make(map[string][]string),
+		},
+		config:   c,
 		internal: make(map[string][]byte),
 	}
+	if c.statsEnabled {
+		rv.stats = &stats{}
+	}
+	if c.streamingCorpusStats {
+		rv.corpus = NewStreamingCorpusStats(c.streamingCorpusStatsWindow)
+	}
 
 	rv.reader = NewReader(rv)
+	if c.sharedAutomata {
+		rv.reader.automata = SharedAutomatonCache()
+	}
 
-	return rv, nil
+	return rv
 }
 
 // Open the index
@@ -60,29 +138,332 @@ func (s *Sear) Close() error {
 }
 
 // Update the index to include this document.
-// Unlike other Bleve indexes, this operation will overwrite
-// a previously indexed document, regardless of the document's
-// identifiers.
+// Unlike other Bleve indexes, in single document mode this
+// operation will overwrite a previously indexed document,
+// regardless of the document's identifiers.  In multi-document
+// mode, a document with the same identifier is replaced, and
+// an error is returned if the index is already full.
 func (s *Sear) Update(doc index.Document) error {
-	if s.doc == nil {
-		s.doc = NewDocument()
+	num, err := s.prepareDocNum(doc)
+	if err != nil {
+		return err
 	}
-	s.doc.Reset(doc)
+	s.analyze(num, doc)
+	s.resetSortedTerms()
+	s.stats.updated()
 
+	err = s.checkLimits(num)
+	if err != nil {
+		return err
+	}
+	s.observe(num)
 	return nil
 }
 
+// prepareDocNum returns the doc number the document should
+// be analyzed into, adding a new document if necessary.
+func (s *Sear) prepareDocNum(doc index.Document) (int, error) {
+	num := s.docNum(doc.ID())
+	if num < 0 {
+		if len(s.docs) >= s.config.maxDocs {
+			return 0, fmt.Errorf("index already contains the maximum of %d documents", s.config.maxDocs)
+		}
+		num = len(s.docs)
+		s.docs = append(s.docs, s.newDocument())
+	} else if s.docs[num].snapshots.Load() > 0 {
+		// copy-on-write, leave the document to the snapshot readers
+		s.docs[num] = s.newDocument()
+	}
+	// identify the document now, even though analysis may be deferred
+	s.docs[num].doc = doc
+	return num, nil
+}
+
+// analyze is safe to call concurrently for different doc numbers
+func (s *Sear) analyze(num int, doc index.Document) {
+	start := time.Now()
+	s.docs[num].Reset(doc)
+	s.stats.analyzed(s.docs[num], uint64(time.Since(start)))
+}
+
+// checkLimits removes the document from the index
+// and returns an error if it exceeds the configured limits,
+// or if configured, has invalid or missing vector fields.
+func (s *Sear) checkLimits(num int) error {
+	if s.config.maxTermsPerField == 0 && !s.config.validateVectors && len(s.config.vectorFields) == 0 {
+		return nil
+	}
+	d := s.docs[num]
+	for i, atf := range d.fieldTokenFreqs {
+		if s.config.maxTermsPerField > 0 && len(atf) > s.config.maxTermsPerField {
+			id := d.doc.ID()
+			s.removeDocNum(num)
+			return fmt.Errorf("document '%s' field '%s' has %d terms, exceeding the maximum of %d",
+				id, d.fieldNames[i], len(atf), s.config.maxTermsPerField)
+		}
+		if s.config.validateVectors {
+			if err := d.vectors[i].validate(); err != nil {
+				id := d.doc.ID()
+				s.removeDocNum(num)
+				return fmt.Errorf("document '%s' vector field '%s' %v", id, d.fieldNames[i], err)
+			}
+		}
+	}
+	for i := range s.config.vectorFields {
+		expected := &s.config.vectorFields[i]
+		var v fieldVector
+		if fieldIdx, err := d.fieldIndex(expected.name); err == nil {
+			v = d.vectors[fieldIdx]
+		}
+		if err := v.check(expected); err != nil {
+			id := d.doc.ID()
+			s.removeDocNum(num)
+			return fmt.Errorf("document '%s' vector field '%s' %v", id, expected.name, err)
+		}
+	}
+	return nil
+}
+
+// observe passes the document to the corpus stats, if they learn
+func (s *Sear) observe(num int) {
+	if o, ok := s.corpus.(CorpusObserver); ok {
+		o.Observe(s.docs[num])
+	}
+}
+
 // Delete document from the index.
-// Unlike other Bleve indexes, this operation will delete
-// the document from the index, regardless of it's identifier.
+// Unlike other Bleve indexes, in single document mode this
+// operation will delete the document from the index, regardless
+// of it's identifier, unless strict delete is configured.
+// In multi-document mode, only a document with this identifier
+// is deleted, and the internal ids of subsequent documents shift
+// down to remain sequential.
 func (s *Sear) Delete(id string) error {
-	s.doc = nil
+	s.stats.deleted()
+	num := s.docNum(id)
+	if num < 0 {
+		return nil
+	}
+	if s.config.strictDelete && s.docs[num].doc.ID() != id {
+		return nil
+	}
+	s.removeDocNum(num)
 	return nil
 }
 
-// Batch is not supported by this index.
+func (s *Sear) removeDocNum(num int) {
+	if s.docs[num].snapshots.Load() == 0 {
+		s.spare = append(s.spare, s.docs[num])
+	}
+	s.docs = append(s.docs[:num], s.docs[num+1:]...)
+	s.resetSortedTerms()
+}
+
+// Batch is only supported in multi-document mode.
+// Deletes are applied first, followed by updates in
+// identifier order, so that internal ids are assigned
+// deterministically.  If the batch would exceed the
+// maximum number of documents, an error is returned
+// and the index is unchanged.
 func (s *Sear) Batch(batch *index.Batch) error {
-	return fmt.Errorf("batch indexing is not supported by this index")
+	if s.config.maxDocs == 1 {
+		return fmt.Errorf("batch indexing is not supported by this index")
+	}
+
+	var deletes, updates []string
+	for id, doc := range batch.IndexOps {
+		if doc == nil {
+			deletes = append(deletes, id)
+		} else {
+			updates = append(updates, id)
+		}
+	}
+	sort.Strings(updates)
+
+	numDocs := len(s.docs)
+	for _, id := range deletes {
+		if s.docNum(id) >= 0 {
+			numDocs--
+		}
+	}
+	for _, id := range updates {
+		if s.docNum(id) < 0 {
+			numDocs++
+		}
+	}
+	if numDocs > s.config.maxDocs {
+		return fmt.Errorf("batch would result in %d documents, exceeding the maximum of %d",
+			numDocs, s.config.maxDocs)
+	}
+
+	for _, id := range deletes {
+		_ = s.Delete(id)
+	}
+
+	nums := make([]int, len(updates))
+	for i, id := range updates {
+		// capacity was checked above
+		nums[i], _ = s.prepareDocNum(batch.IndexOps[id])
+	}
+	if s.analysisQueue != nil && len(updates) > 1 {
+		var wg sync.WaitGroup
+		wg.Add(len(updates))
+		for i, id := range updates {
+			num, doc := nums[i], batch.IndexOps[id]
+			s.analysisQueue.Queue(func() {
+				s.analyze(num, doc)
+				wg.Done()
+			})
+		}
+		wg.Wait()
+	} else {
+		for i, id := range updates {
+			s.analyze(nums[i], batch.IndexOps[id])
+		}
+	}
+	s.resetSortedTerms()
+
+	// check in descending order, removals shift subsequent doc numbers
+	sort.Sort(sort.Reverse(sort.IntSlice(nums)))
+	var err error
+	for _, num := range nums {
+		s.stats.updated()
+		if lerr := s.checkLimits(num); lerr != nil {
+			err = lerr
+		} else {
+			s.observe(num)
+		}
+	}
+
+	for k, v := range batch.InternalOps {
+		if v == nil {
+			delete(s.internal, k)
+		} else {
+			s.internal[k] = v
+		}
+	}
+
+	s.stats.batched()
+	if cb := batch.PersistedCallback(); cb != nil {
+		cb(err)
+	}
+	return err
+}
+
+// docNum returns the internal doc number of the document with
+// the provided identifier, or -1 if there is no such document.
+// In single document mode, the identifier is ignored.
+func (s *Sear) docNum(id string) int {
+	if s.config.maxDocs == 1 {
+		if len(s.docs) > 0 {
+			return 0
+		}
+		return -1
+	}
+	for i, d := range s.docs {
+		if d.doc.ID() == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *Sear) newDocument() *Document {
+	if n := len(s.spare); n > 0 {
+		rv := s.spare[n-1]
+		s.spare = s.spare[:n-1]
+		return rv
+	}
+	return NewDocument()
+}
+
+func (s *docSet) resetSortedTerms() {
+	for k := range s.sortedTerms {
+		s.sortedTerms[k] = s.sortedTerms[k][:0]
+	}
+}
+
+// SortedTermsForField returns the sorted, de-duplicated terms
+// used in the named field across all indexed documents.
+func (s *docSet) SortedTermsForField(field string) ([]string, error) {
+	if len(s.docs) == 1 && !s.isolated {
+		return s.docs[0].SortedTermsForField(field)
+	}
+
+	terms, ok := s.sortedTerms[field]
+	if ok && len(terms) > 0 {
+		return terms, nil
+	}
+	for _, d := range s.docs {
+		if s.isolated {
+			if fieldIdx, err := d.fieldIndex(field); err == nil {
+				terms = mergeSortedTerms(terms, d.appendSortedTerms(nil, fieldIdx))
+			}
+			continue
+		}
+		dterms, err := d.SortedTermsForField(field)
+		if err == nil {
+			terms = mergeSortedTerms(terms, dterms)
+		}
+	}
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("no field named: %s", field)
+	}
+	s.sortedTerms[field] = terms
+	return terms, nil
+}
+
+// mergeSortedTerms merges b into a, both sorted, without duplicates
+func mergeSortedTerms(a, b []string) []string {
+	n := len(a)
+	a = append(a, b...)
+	if n == 0 {
+		return a
+	}
+	sort.Strings(a)
+	j := 0
+	for i := 1; i < len(a); i++ {
+		if a[i] != a[j] {
+			j++
+			a[j] = a[i]
+		}
+	}
+	return a[:j+1]
+}
+
+// docFreq returns the number of indexed documents
+// using term in the named field.
+func (s *docSet) docFreq(field, term string) uint64 {
+	var rv uint64
+	for _, d := range s.docs {
+		atf, _, err := d.TokenFreqsAndLen(field)
+		if err == nil {
+			if _, ok := atf[term]; ok {
+				rv++
+			}
+		}
+	}
+	return rv
+}
+
+// SetAutomatonCache configures this index to compile regexp and
+// Levenshtein automata through the provided cache, which may be
+// shared with other Sear instances used by other goroutines.
+func (s *Sear) SetAutomatonCache(c *AutomatonCache) {
+	s.reader.automata = c
+}
+
+// snapshotAutomata returns the automaton cache of this index's
+// snapshot readers, bounded like the reader's own caches
+func (s *Sear) snapshotAutomata() *AutomatonCache {
+	if s.automata == nil {
+		capacity := max(s.config.regexpCacheSize, s.config.levCacheSize)
+		if s.config.regexpCacheSize == 0 || s.config.levCacheSize == 0 {
+			capacity = 0
+		}
+		s.automata = NewAutomatonCacheWithCapacity(capacity)
+	}
+	return s.automata
 }
 
 // SetInternal sets a value in the index internal storage.
@@ -97,13 +478,35 @@ func (s *Sear) DeleteInternal(key []byte) error {
 	return nil
 }
 
+// SetIsolatedReaders controls whether Reader returns isolated
+// point-in-time snapshots, instead of the shared reader.
+func (s *Sear) SetIsolatedReaders(isolated bool) {
+	s.config.isolatedReaders = isolated
+}
+
 // Reader returns a reader for this index.
-// Unlike other Bleve indexes, this reader is NOT isolated.
+// Unlike other Bleve indexes, by default this reader is NOT
+// isolated.  If isolated readers have been enabled, the reader
+// is a snapshot which continues to see the documents indexed
+// at the time it was obtained, until it is closed.
 func (s *Sear) Reader() (index.IndexReader, error) {
+	if s.config.isolatedReaders {
+		return s.reader.snapshot(), nil
+	}
 	return s.reader, nil
 }
 
-// StatsMap returns stats about this index.
+// StatsMap returns stats about this index,
+// or nil if stats are not enabled.
 func (s *Sear) StatsMap() map[string]interface{} {
-	return s.stats
+	if s.stats == nil {
+		return nil
+	}
+	return s.Stats().ToMap()
+}
+
+// Stats returns a copy of the current stats about this index.
+// It is safe to call concurrently with other methods.
+func (s *Sear) Stats() Stats {
+	return s.stats.snapshot()
 }
@@ -16,8 +16,10 @@ package sear
 
 import (
 	"bytes"
+	"fmt"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
 
 	index "github.com/blevesearch/bleve_index_api"
@@ -358,7 +360,7 @@ func assertTermFieldReader(t *testing.T, tfr index.TermFieldReader, expected []*
 	tfd, err := tfr.Next(nil)
 	for err == nil && tfd != nil {
 		actual = append(actual, tfd)
-		tfd, err = tfr.Next(tfd)
+		tfd, err = tfr.Next(nil)
 	}
 	if err != nil {
 		t.Fatalf("error getting next tfd: %v", err)
@@ -785,3 +787,479 @@ func BenchmarkFieldDictFuzzy(b *testing.B) {
 		}
 	}
 }
+
+func TestMultiDocument(t *testing.T) {
+	_, err := NewMulti(0)
+	if err == nil {
+		t.Errorf("expected error for max docs 0")
+	}
+	_, err = NewMulti(1)
+	if err == nil {
+		t.Errorf("expected error for max docs 1, single document mode")
+	}
+	_, err = NewMulti(MaxDocs + 1)
+	if err == nil {
+		t.Errorf("expected error for max docs %d", MaxDocs+1)
+	}
+
+	idx, err := NewMulti(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := idx.Reader()
+	if err != nil {
+		t.Fatalf("error getting index reader: %v", err)
+	}
+	assertEmptyIndex(t, reader)
+
+	mapAndUpdateDocument(t, idx, "a", map[string]interface{}{
+		"name":   "marty",
+		"slogan": "code match",
+	})
+	mapAndUpdateDocument(t, idx, "b", map[string]interface{}{
+		"name":   "steve",
+		"slogan": "golf match",
+	})
+	mapAndUpdateDocument(t, idx, "c", map[string]interface{}{
+		"title": "zoo",
+	})
+
+	// index is full
+	err = idx.Update(newTestDoc("d"))
+	if err == nil {
+		t.Errorf("expected error indexing beyond max docs")
+	}
+
+	count, err := reader.DocCount()
+	if err != nil {
+		t.Fatalf("error getting doc count: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("expected doc count 3, got %d", count)
+	}
+
+	for i, id := range []string{"a", "b", "c"} {
+		intID, err := reader.InternalID(id)
+		if err != nil {
+			t.Fatalf("error getting internal id: %v", err)
+		}
+		if !bytes.Equal(intID, internalDocIDs[i]) {
+			t.Errorf("expected internal id %v for %s, got %v", internalDocIDs[i], id, intID)
+		}
+		extID, err := reader.ExternalID(intID)
+		if err != nil {
+			t.Fatalf("error getting external id: %v", err)
+		}
+		if extID != id {
+			t.Errorf("expected external id %s, got %s", id, extID)
+		}
+	}
+
+	tfr, err := reader.TermFieldReader(nil, []byte("match"), "slogan", true, false, false)
+	if err != nil {
+		t.Fatalf("error getting term field reader: %v", err)
+	}
+	assertTermFieldReader(t, tfr, []*index.TermFieldDoc{
+		{Term: "match", ID: internalDocIDs[0], Freq: 1},
+		{Term: "match", ID: internalDocIDs[1], Freq: 1},
+	})
+
+	tfr, err = reader.TermFieldReader(nil, []byte("match"), "slogan", false, false, false)
+	if err != nil {
+		t.Fatalf("error getting term field reader: %v", err)
+	}
+	tfd, err := tfr.Advance(internalDocIDs[1], nil)
+	if err != nil || tfd == nil || !bytes.Equal(tfd.ID, internalDocIDs[1]) {
+		t.Errorf("expected advance to doc 1, got %v, err: %v", tfd, err)
+	}
+	tfd, err = tfr.Advance(internalDocIDs[0], nil)
+	if err != nil || tfd == nil || !bytes.Equal(tfd.ID, internalDocIDs[0]) {
+		t.Errorf("expected backwards advance to doc 0, got %v, err: %v", tfd, err)
+	}
+
+	docIDReaderAll, err := reader.DocIDReaderAll()
+	if err != nil {
+		t.Fatalf("error getting doc id reader all: %v", err)
+	}
+	assertDocIDReader(t, docIDReaderAll, [][]byte{internalDocIDs[0], internalDocIDs[1], internalDocIDs[2]})
+	docID, err := docIDReaderAll.Advance(internalDocIDs[1])
+	if err != nil || !bytes.Equal(docID, internalDocIDs[1]) {
+		t.Errorf("expected backwards advance to doc 1, got %v, err: %v", docID, err)
+	}
+
+	docIDReaderOnly, err := reader.DocIDReaderOnly([]string{"c", "a", "x"})
+	if err != nil {
+		t.Fatalf("error getting doc id reader only: %v", err)
+	}
+	assertDocIDReader(t, docIDReaderOnly, [][]byte{internalDocIDs[0], internalDocIDs[2]})
+
+	fd, err := reader.FieldDict("slogan")
+	if err != nil {
+		t.Fatalf("error getting field dictionary: %v", err)
+	}
+	counts := map[string]uint64{}
+	next, err := fd.Next()
+	for err == nil && next != nil {
+		counts[next.Term] = next.Count
+		next, err = fd.Next()
+	}
+	if err != nil {
+		t.Fatalf("error iterating field dictionary: %v", err)
+	}
+	expectedCounts := map[string]uint64{"code": 1, "golf": 1, "match": 2}
+	if !reflect.DeepEqual(expectedCounts, counts) {
+		t.Errorf("expected dictionary counts %v, got %v", expectedCounts, counts)
+	}
+
+	fd, err = reader.FieldDictPrefix("name", []byte("m"))
+	if err != nil {
+		t.Fatalf("error getting field dictionary prefix: %v", err)
+	}
+	assertTermDictionary(t, fd, []string{"marty"})
+
+	fdc, err := reader.(index.IndexReaderContains).FieldDictContains("name")
+	if err != nil {
+		t.Fatalf("error getting field dict contains: %v", err)
+	}
+	for _, term := range []string{"marty", "steve"} {
+		found, err := fdc.Contains([]byte(term))
+		if err != nil || !found {
+			t.Errorf("expected to find term '%s', err: %v", term, err)
+		}
+	}
+
+	fields, err := reader.Fields()
+	if err != nil {
+		t.Fatalf("error getting index fields: %v", err)
+	}
+	assertAllAndOnlyValues(t, []string{"name", "slogan", "title", "_all"}, fields)
+
+	dvr, err := reader.DocValueReader([]string{"name"})
+	if err != nil {
+		t.Fatalf("error getting doc value reader: %v", err)
+	}
+	var termsSeen []string
+	err = dvr.VisitDocValues(internalDocIDs[1], func(field string, term []byte) {
+		termsSeen = append(termsSeen, string(term))
+	})
+	if err != nil {
+		t.Fatalf("error visiting doc values: %v", err)
+	}
+	assertAllAndOnlyValues(t, []string{"steve"}, termsSeen)
+	err = dvr.VisitDocValues(internalDocIDs[3], func(field string, term []byte) {})
+	if err == nil {
+		t.Errorf("expected error visiting doc values of unknown doc")
+	}
+
+	// replace b in place
+	mapAndUpdateDocument(t, idx, "b", map[string]interface{}{
+		"name": "tiger",
+	})
+	intID, err := reader.InternalID("b")
+	if err != nil || !bytes.Equal(intID, internalDocIDs[1]) {
+		t.Errorf("expected b to keep internal id %v, got %v, err: %v", internalDocIDs[1], intID, err)
+	}
+
+	// delete a, subsequent docs shift down
+	err = idx.Delete("a")
+	if err != nil {
+		t.Fatalf("error deleting doc: %v", err)
+	}
+	extID, err := reader.ExternalID(internalDocIDs[0])
+	if err != nil || extID != "b" {
+		t.Errorf("expected doc 0 to be b, got %s, err: %v", extID, err)
+	}
+	fd, err = reader.FieldDict("name")
+	if err != nil {
+		t.Fatalf("error getting field dictionary: %v", err)
+	}
+	assertTermDictionary(t, fd, []string{"tiger"})
+
+	for _, id := range []string{"b", "c", "missing"} {
+		err = idx.Delete(id)
+		if err != nil {
+			t.Fatalf("error deleting doc: %v", err)
+		}
+	}
+	assertEmptyIndex(t, reader)
+}
+
+func TestBatch(t *testing.T) {
+	single, err := New("", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = single.Batch(index.NewBatch())
+	if err == nil {
+		t.Errorf("expected batch error in single document mode")
+	}
+
+	idx, err := NewMulti(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mapAndUpdateDocument(t, idx, "a", map[string]interface{}{
+		"name": "marty",
+	})
+
+	newDoc := func(id, name string) index.Document {
+		doc := newTestDoc(id)
+		doc.AddField(newTestField("name", []byte(name)))
+		return doc
+	}
+
+	batch := index.NewBatch()
+	batch.Update(newDoc("d", "dave"))
+	batch.Update(newDoc("c", "chris"))
+	batch.Update(newDoc("b", "bob"))
+	batch.SetInternal([]byte("k"), []byte("v"))
+	var callbackErr error
+	callbackCalled := false
+	batch.SetPersistedCallback(func(err error) {
+		callbackCalled = true
+		callbackErr = err
+	})
+	err = idx.Batch(batch)
+	if err == nil {
+		t.Errorf("expected error for batch exceeding max docs")
+	}
+	reader, err := idx.Reader()
+	if err != nil {
+		t.Fatalf("error getting index reader: %v", err)
+	}
+	count, _ := reader.DocCount()
+	if count != 1 {
+		t.Errorf("expected failed batch to leave 1 doc, got %d", count)
+	}
+
+	batch.Delete("a")
+	err = idx.Batch(batch)
+	if err != nil {
+		t.Fatalf("error executing batch: %v", err)
+	}
+	if !callbackCalled || callbackErr != nil {
+		t.Errorf("expected persisted callback with nil error, called: %t, err: %v", callbackCalled, callbackErr)
+	}
+
+	for i, id := range []string{"b", "c", "d"} {
+		extID, err := reader.ExternalID(internalDocIDs[i])
+		if err != nil || extID != id {
+			t.Errorf("expected doc %d to be %s, got %s, err: %v", i, id, extID, err)
+		}
+	}
+	val, err := reader.GetInternal([]byte("k"))
+	if err != nil || string(val) != "v" {
+		t.Errorf("expected internal value v, got %s, err: %v", val, err)
+	}
+
+	tfr, err := reader.TermFieldReader(nil, []byte("chris"), "name", false, false, false)
+	if err != nil {
+		t.Fatalf("error getting term field reader: %v", err)
+	}
+	assertTermFieldReader(t, tfr, []*index.TermFieldDoc{
+		{Term: "chris", ID: internalDocIDs[1]},
+	})
+}
+
+func TestIsolatedReader(t *testing.T) {
+	idx, err := New("", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := idx.(*Sear)
+	s.SetIsolatedReaders(true)
+
+	mapAndUpdateDocument(t, idx, "a", map[string]interface{}{
+		"name": "marty",
+	})
+
+	snapshot, err := idx.Reader()
+	if err != nil {
+		t.Fatalf("error getting index reader: %v", err)
+	}
+
+	mapAndUpdateDocument(t, idx, "b", map[string]interface{}{
+		"name": "steve",
+	})
+
+	// snapshot still sees the original document
+	extID, err := snapshot.ExternalID(internalDocID)
+	if err != nil || extID != "a" {
+		t.Errorf("expected snapshot to see doc a, got %s, err: %v", extID, err)
+	}
+	fd, err := snapshot.FieldDict("name")
+	if err != nil {
+		t.Fatalf("error getting field dictionary: %v", err)
+	}
+	assertTermDictionary(t, fd, []string{"marty"})
+
+	// a new reader sees the new document
+	current, err := idx.Reader()
+	if err != nil {
+		t.Fatalf("error getting index reader: %v", err)
+	}
+	fd, err = current.FieldDict("name")
+	if err != nil {
+		t.Fatalf("error getting field dictionary: %v", err)
+	}
+	assertTermDictionary(t, fd, []string{"steve"})
+
+	// deleting does not affect the snapshots either
+	err = idx.Delete("b")
+	if err != nil {
+		t.Fatalf("error deleting doc: %v", err)
+	}
+	tfr, err := current.TermFieldReader(nil, []byte("steve"), "name", false, false, false)
+	if err != nil {
+		t.Fatalf("error getting term field reader: %v", err)
+	}
+	if tfr.Count() != 1 {
+		t.Errorf("expected snapshot to still find steve")
+	}
+
+	pinned := []*Document{snapshot.(*Reader).ds.docs[0], current.(*Reader).ds.docs[0]}
+	for _, r := range []index.IndexReader{snapshot, current} {
+		err = r.Close()
+		if err != nil {
+			t.Fatalf("error closing reader: %v", err)
+		}
+	}
+	for _, d := range pinned {
+		if d.snapshots.Load() != 0 {
+			t.Errorf("expected document unpinned after close, got %d", d.snapshots.Load())
+		}
+	}
+
+	// closed readers are empty
+	assertEmptyIndex(t, snapshot)
+
+	// without isolation, the shared reader is returned
+	s.SetIsolatedReaders(false)
+	r1, _ := idx.Reader()
+	r2, _ := idx.Reader()
+	if r1 != r2 {
+		t.Errorf("expected shared reader")
+	}
+}
+
+func TestIsolatedReaderConcurrent(t *testing.T) {
+	idx, err := New("", map[string]interface{}{
+		ConfigIsolatedReaders:      true,
+		ConfigStreamingCorpusStats: true,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := idx.(*Sear)
+
+	// snapshots are post-processed on other goroutines,
+	// while the owner keeps indexing
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		mapAndUpdateDocument(t, idx, fmt.Sprintf("%d", i), map[string]interface{}{
+			"name": "marty",
+		})
+		err = s.SetInternal([]byte("k"), []byte("v"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		snapshot, err := idx.Reader()
+		if err != nil {
+			t.Fatalf("error getting index reader: %v", err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() {
+				_ = snapshot.Close()
+			}()
+			fd, err := snapshot.(index.IndexReaderRegexp).FieldDictRegexp("name", "ma.*")
+			if err != nil {
+				t.Errorf("error getting field dict regexp: %v", err)
+				return
+			}
+			assertTermDictionary(t, fd, []string{"marty"})
+			_, err = snapshot.(index.IndexReaderFuzzy).FieldDictFuzzy("name", "mart", 1, "")
+			if err != nil {
+				t.Errorf("error getting field dict fuzzy: %v", err)
+			}
+			_, err = snapshot.TermFieldReader(nil, []byte("marty"), "name", true, true, false)
+			if err != nil {
+				t.Errorf("error getting term field reader: %v", err)
+			}
+			_, err = snapshot.DocCount()
+			if err != nil {
+				t.Errorf("error getting doc count: %v", err)
+			}
+			v, err := snapshot.GetInternal([]byte("k"))
+			if err != nil || string(v) != "v" {
+				t.Errorf("expected internal value v, got %s, err: %v", v, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// the snapshots shared the automata compiled through the index
+	stats := s.automata.Stats()
+	if stats.RegexpEntries != 1 || stats.RegexpHits+stats.RegexpMisses != 20 {
+		t.Errorf("expected a single shared regexp, got %+v", stats)
+	}
+	if stats.LevenshteinEntries != 1 || stats.LevenshteinHits+stats.LevenshteinMisses != 20 {
+		t.Errorf("expected a single shared Levenshtein automaton, got %+v", stats)
+	}
+}
+
+func TestReaderDocument(t *testing.T) {
+	idx, err := New("", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader, err := idx.Reader()
+	if err != nil {
+		t.Fatalf("error getting index reader: %v", err)
+	}
+
+	// empty index
+	doc, err := reader.Document("a")
+	if err != ErrDocumentNotFound || doc != nil {
+		t.Errorf("expected document not found, got %v, err: %v", doc, err)
+	}
+
+	bleveDoc := newTestDoc("a")
+	stored := newTestField("name", []byte("marty"))
+	stored.options |= index.StoreField
+	bleveDoc.AddField(stored)
+	bleveDoc.AddField(newTestField("title", []byte("developer")))
+	err = idx.Update(bleveDoc)
+	if err != nil {
+		t.Fatalf("error indexing document: %v", err)
+	}
+
+	doc, err = reader.Document("b")
+	if err != ErrDocumentNotFound || doc != nil {
+		t.Errorf("expected document not found, got %v, err: %v", doc, err)
+	}
+
+	doc, err = reader.Document("a")
+	if err != nil {
+		t.Fatalf("error getting document: %v", err)
+	}
+	if doc.ID() != "a" {
+		t.Errorf("expected document id a, got %s", doc.ID())
+	}
+	var fieldsSeen []string
+	doc.VisitFields(func(field index.Field) {
+		fieldsSeen = append(fieldsSeen, field.Name())
+		if string(field.Value()) != "marty" {
+			t.Errorf("expected stored value marty, got %s", field.Value())
+		}
+	})
+	assertAllAndOnlyValues(t, []string{"name"}, fieldsSeen)
+	if doc.HasComposite() {
+		t.Errorf("expected stored document to have no composite fields")
+	}
+	doc.VisitComposite(func(field index.CompositeField) {
+		t.Errorf("unexpected composite field: %s", field.Name())
+	})
+}
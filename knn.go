@@ -0,0 +1,90 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//              http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build vectors
+// +build vectors
+
+package sear
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	index "github.com/blevesearch/bleve_index_api"
+)
+
+// KNNQuery is a Query matching the current document when its
+// vector field is among the K closest to Vector (and within any
+// threshold in Params).  It is scored like bleve's kNN scorer:
+// the inverse of the squared distance for l2_norm, otherwise
+// the similarity, multiplied by the boost.
+type KNNQuery struct {
+	Field  string
+	Vector []float32
+	K      int64           // zero is treated as 1, negative is an error
+	Params json.RawMessage // see VectorReader
+	Boost  float64         // zero is treated as 1
+}
+
+// maxKNNScore is the score of an exact l2_norm match
+const maxKNNScore = math.MaxFloat32
+
+// Match implements Query.
+func (q *KNNQuery) Match(ctx context.Context, r index.IndexReader) (bool, float64, error) {
+	vir, ok := r.(index.VectorIndexReader)
+	if !ok {
+		return false, 0, fmt.Errorf("reader does not support vector search")
+	}
+	k := q.K
+	if k < 0 {
+		return false, 0, fmt.Errorf("invalid k: %d, must not be negative", k)
+	}
+	if k == 0 {
+		k = 1
+	}
+	vr, err := vir.VectorReader(ctx, q.Vector, q.Field, k, q.Params, nil)
+	if err != nil {
+		return false, 0, err
+	}
+	defer func() { _ = vr.Close() }()
+
+	vd, err := vr.Next(nil)
+	if err != nil || vd == nil {
+		return false, 0, err
+	}
+	var similarity string
+	if vfr, ok := vr.(*VectorFieldReader); ok {
+		similarity = vfr.similarity
+	}
+	boost := q.Boost
+	if boost == 0 {
+		boost = 1
+	}
+	return true, knnScore(similarity, vd.Score, boost), nil
+}
+
+// knnScore converts the score of a VectorDoc into
+// the score bleve would give the document match
+func knnScore(similarity string, score, boost float64) float64 {
+	if similarity == index.EuclideanDistance || similarity == "" {
+		if score == 0 {
+			// exact match, not boosted
+			return maxKNNScore
+		}
+		score = 1 / score
+	}
+	return score * boost
+}
@@ -0,0 +1,80 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//              http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sear
+
+import (
+	"container/list"
+)
+
+// lruCache is a map which, when it has a capacity, evicts the
+// least recently used entry to stay within it.  It is not safe
+// for concurrent use.
+type lruCache[K comparable, V any] struct {
+	capacity int // 0 means unlimited
+	entries  map[K]*list.Element
+	order    *list.List // most recently used first
+}
+
+type lruEntry[K comparable, V any] struct {
+	key K
+	val V
+}
+
+func newLRUCache[K comparable, V any](capacity int) *lruCache[K, V] {
+	return &lruCache[K, V]{
+		capacity: capacity,
+		entries:  make(map[K]*list.Element),
+		order:    list.New(),
+	}
+}
+
+func (c *lruCache[K, V]) Get(key K) (V, bool) {
+	e, ok := c.entries[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*lruEntry[K, V]).val, true
+}
+
+func (c *lruCache[K, V]) Put(key K, val V) {
+	if e, ok := c.entries[key]; ok {
+		e.Value.(*lruEntry[K, V]).val = val
+		c.order.MoveToFront(e)
+		return
+	}
+	if c.capacity > 0 && c.order.Len() >= c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry[K, V]).key)
+	}
+	c.entries[key] = c.order.PushFront(&lruEntry[K, V]{key: key, val: val})
+}
+
+// SetCapacity changes the capacity, evicting the least recently
+// used entries beyond it
+func (c *lruCache[K, V]) SetCapacity(capacity int) {
+	c.capacity = capacity
+	for capacity > 0 && c.order.Len() > capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry[K, V]).key)
+	}
+}
+
+func (c *lruCache[K, V]) Len() int {
+	return c.order.Len()
+}
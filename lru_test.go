@@ -0,0 +1,86 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//              http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sear
+
+import (
+	"testing"
+
+	index "github.com/blevesearch/bleve_index_api"
+)
+
+func TestLRUCache(t *testing.T) {
+	c := newLRUCache[string, int](2)
+	c.Put("a", 1)
+	c.Put("b", 2)
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Errorf("expected a=1, got %d, %t", v, ok)
+	}
+	// b is least recently used
+	c.Put("c", 3)
+	if _, ok := c.Get("b"); ok {
+		t.Errorf("expected b to be evicted")
+	}
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Errorf("expected a=1, got %d, %t", v, ok)
+	}
+	c.Put("c", 4)
+	if v, ok := c.Get("c"); !ok || v != 4 {
+		t.Errorf("expected c=4, got %d, %t", v, ok)
+	}
+	if c.Len() != 2 {
+		t.Errorf("expected 2 entries, got %d", c.Len())
+	}
+
+	unlimited := newLRUCache[int, int](0)
+	for i := 0; i < 100; i++ {
+		unlimited.Put(i, i)
+	}
+	if unlimited.Len() != 100 {
+		t.Errorf("expected 100 entries, got %d", unlimited.Len())
+	}
+}
+
+func TestRegexpCacheLRU(t *testing.T) {
+	idx, err := New("", map[string]interface{}{
+		ConfigRegexpCacheSize: 2,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := idx.(*Sear)
+	mapAndUpdateDocument(t, idx, "a", map[string]interface{}{
+		"name": "marty",
+	})
+	reader, err := idx.Reader()
+	if err != nil {
+		t.Fatalf("error getting index reader: %v", err)
+	}
+
+	// a.* is used frequently, so is never evicted
+	for _, re := range []string{"a.*", "b.*", "a.*", "c.*", "a.*", "d.*", "a.*"} {
+		_, err = reader.(index.IndexReaderRegexp).FieldDictRegexp("name", re)
+		if err != nil {
+			t.Fatalf("error getting field dict regexp: %v", err)
+		}
+	}
+	stats := s.Stats()
+	if stats.TotRegexpCacheHits != 3 || stats.TotRegexpCacheMisses != 4 {
+		t.Errorf("expected 3 hits and 4 misses, got %d and %d",
+			stats.TotRegexpCacheHits, stats.TotRegexpCacheMisses)
+	}
+	if s.reader.velregCache.Len() != 2 {
+		t.Errorf("expected 2 cached regexps, got %d", s.reader.velregCache.Len())
+	}
+}
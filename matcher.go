@@ -0,0 +1,125 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//              http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sear
+
+import (
+	"context"
+	"fmt"
+
+	index "github.com/blevesearch/bleve_index_api"
+)
+
+// Mapping converts application data into a document which
+// can be indexed by Sear.  Typically this is a thin adapter
+// around a bleve mapping.IndexMapping.
+type Mapping interface {
+	MapDocument(data interface{}) (index.Document, error)
+}
+
+// MappingFunc allows an ordinary function to be used as a Mapping.
+type MappingFunc func(data interface{}) (index.Document, error)
+
+// MapDocument calls f(data).
+func (f MappingFunc) MapDocument(data interface{}) (index.Document, error) {
+	return f(data)
+}
+
+// Query is a pre-built query which can be executed against
+// an index reader.  Typically this is a thin adapter around
+// a bleve query.Query, which builds a searcher using the
+// provided reader and reports whether it produced a hit.
+type Query interface {
+	Match(ctx context.Context, r index.IndexReader) (matched bool, score float64, err error)
+}
+
+// QueryFunc allows an ordinary function to be used as a Query.
+type QueryFunc func(ctx context.Context, r index.IndexReader) (bool, float64, error)
+
+// Match calls f(ctx, r).
+func (f QueryFunc) Match(ctx context.Context, r index.IndexReader) (bool, float64, error) {
+	return f(ctx, r)
+}
+
+// Matcher answers the question, "would this document have
+// matched this query?"  It owns a Sear index, a mapping and a
+// pre-built query, and reuses the same Reader (and its caches)
+// for every document it is asked to match.
+type Matcher struct {
+	s       *Sear
+	mapping Mapping
+	query   Query
+}
+
+// NewMatcher returns a Matcher which evaluates query against
+// documents produced by mapping.  If mapping is nil, the
+// documents passed to Match must implement index.Document.
+func NewMatcher(mapping Mapping, query Query) (*Matcher, error) {
+	if query == nil {
+		return nil, fmt.Errorf("matcher requires a query")
+	}
+	idx, err := New(Name, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	err = idx.Open()
+	if err != nil {
+		return nil, err
+	}
+	return &Matcher{
+		s:       idx.(*Sear),
+		mapping: mapping,
+		query:   query,
+	}, nil
+}
+
+// Match indexes the provided document, replacing any previous
+// document, and executes the query against it.
+func (m *Matcher) Match(doc interface{}) (matched bool, score float64, err error) {
+	idoc, err := mapDocument(m.mapping, doc)
+	if err != nil {
+		return false, 0, err
+	}
+	err = m.s.Update(idoc)
+	if err != nil {
+		return false, 0, err
+	}
+	return m.query.Match(context.Background(), m.s.reader)
+}
+
+// mapDocument uses the mapping, when provided, to convert
+// application data into an index.Document.
+func mapDocument(mapping Mapping, doc interface{}) (index.Document, error) {
+	if mapping != nil {
+		idoc, err := mapping.MapDocument(doc)
+		if err != nil {
+			return nil, fmt.Errorf("error mapping document: %v", err)
+		}
+		return idoc, nil
+	}
+	if idoc, ok := doc.(index.Document); ok {
+		return idoc, nil
+	}
+	return nil, fmt.Errorf("no mapping, document must implement index.Document, got %T", doc)
+}
+
+// Index returns the Sear index used by this Matcher.
+func (m *Matcher) Index() *Sear {
+	return m.s
+}
+
+// Close the Matcher and its underlying index.
+func (m *Matcher) Close() error {
+	return m.s.Close()
+}
@@ -0,0 +1,133 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//              http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sear
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	index "github.com/blevesearch/bleve_index_api"
+)
+
+// testTermQuery is a minimal stand-in for a bleve term query
+type testTermQuery struct {
+	field string
+	term  string
+}
+
+func (q *testTermQuery) Match(ctx context.Context, r index.IndexReader) (bool, float64, error) {
+	tfr, err := r.TermFieldReader(ctx, []byte(q.term), q.field, true, true, false)
+	if err != nil {
+		return false, 0, err
+	}
+	tfd, err := tfr.Next(nil)
+	if err != nil || tfd == nil {
+		return false, 0, err
+	}
+	return true, float64(tfd.Freq) * tfd.Norm, nil
+}
+
+func testMapping(data interface{}) (index.Document, error) {
+	m, ok := data.(map[string]string)
+	if !ok {
+		return nil, fmt.Errorf("unexpected document type %T", data)
+	}
+	doc := newTestDoc(m["_id"])
+	for k, v := range m {
+		if k != "_id" {
+			doc.AddField(newTestField(k, []byte(v)))
+		}
+	}
+	return doc, nil
+}
+
+func TestMatcher(t *testing.T) {
+	m, err := NewMatcher(MappingFunc(testMapping), &testTermQuery{field: "name", term: "marty"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		cerr := m.Close()
+		if cerr != nil {
+			t.Fatalf("error closing matcher: %v", cerr)
+		}
+	}()
+
+	tests := []struct {
+		doc     map[string]string
+		matched bool
+	}{
+		{
+			doc:     map[string]string{"_id": "a", "name": "marty schoch"},
+			matched: true,
+		},
+		{
+			doc:     map[string]string{"_id": "b", "name": "steve yen"},
+			matched: false,
+		},
+		{
+			doc:     map[string]string{"_id": "c", "title": "marty"},
+			matched: false,
+		},
+		{
+			doc:     map[string]string{"_id": "d", "name": "marty"},
+			matched: true,
+		},
+	}
+
+	for _, test := range tests {
+		matched, score, err := m.Match(test.doc)
+		if err != nil {
+			t.Fatalf("error matching %s: %v", test.doc["_id"], err)
+		}
+		if matched != test.matched {
+			t.Errorf("expected doc %s matched %t, got %t", test.doc["_id"], test.matched, matched)
+		}
+		if matched && score <= 0 {
+			t.Errorf("expected positive score for doc %s, got %f", test.doc["_id"], score)
+		}
+		if !matched && score != 0 {
+			t.Errorf("expected zero score for doc %s, got %f", test.doc["_id"], score)
+		}
+	}
+}
+
+func TestMatcherWithoutMapping(t *testing.T) {
+	m, err := NewMatcher(nil, &testTermQuery{field: "name", term: "marty"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc := newTestDoc("a")
+	doc.AddField(newTestField("name", []byte("marty")))
+	matched, _, err := m.Match(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !matched {
+		t.Errorf("expected document to match")
+	}
+
+	_, _, err = m.Match(map[string]string{"name": "marty"})
+	if err == nil {
+		t.Errorf("expected error matching unmapped document")
+	}
+
+	_, err = NewMatcher(nil, nil)
+	if err == nil {
+		t.Errorf("expected error creating matcher without query")
+	}
+}
@@ -0,0 +1,388 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//              http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sear
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// RequiredTermsQuery is implemented by queries which can only
+// match documents containing at least one of a known set of terms.
+// The Percolator uses these terms to avoid executing queries which
+// cannot possibly match the current document.
+type RequiredTermsQuery interface {
+	Query
+
+	// RequiredTerms returns terms keyed by field name.  A document
+	// must contain at least one of these terms, in the named field,
+	// for the query to match.  An empty result means the query has
+	// no such requirement, and it will be executed for every document.
+	// Terms are compared with those indexed, so must be as produced
+	// by analysis, see Percolator.AnalyzeTerms.
+	RequiredTerms() map[string][]string
+}
+
+type requiredTermsQuery struct {
+	Query
+	required map[string][]string
+}
+
+func (q *requiredTermsQuery) RequiredTerms() map[string][]string {
+	return q.required
+}
+
+// RequireTerms returns q as a RequiredTermsQuery, requiring at least
+// one of the terms, for example a term query's term, or the analyzed
+// terms of a match query's text.
+func RequireTerms(q Query, required map[string][]string) RequiredTermsQuery {
+	return &requiredTermsQuery{
+		Query:    q,
+		required: required,
+	}
+}
+
+// ConjunctionRequiredTerms returns the required terms of a conjunction
+// of queries with the provided required terms, which are those of the
+// query requiring the fewest terms.
+func ConjunctionRequiredTerms(required ...map[string][]string) map[string][]string {
+	var rv map[string][]string
+	rvCount := -1
+	for _, r := range required {
+		count := countRequiredTerms(r)
+		if count > 0 && (rvCount < 0 || count < rvCount) {
+			rv, rvCount = r, count
+		}
+	}
+	return rv
+}
+
+// DisjunctionRequiredTerms returns the required terms of a disjunction
+// of queries with the provided required terms, which are all of them,
+// unless any query has no requirement.
+func DisjunctionRequiredTerms(required ...map[string][]string) map[string][]string {
+	rv := make(map[string][]string)
+	for _, r := range required {
+		if countRequiredTerms(r) == 0 {
+			return nil
+		}
+		for field, terms := range r {
+			rv[field] = append(rv[field], terms...)
+		}
+	}
+	return rv
+}
+
+func countRequiredTerms(required map[string][]string) int {
+	var rv int
+	for _, terms := range required {
+		rv += len(terms)
+	}
+	return rv
+}
+
+// PercolatorMatch identifies a registered query which matched
+// the current document.
+type PercolatorMatch struct {
+	ID    string
+	Score float64
+}
+
+type percolatorQuery struct {
+	id       string
+	query    Query
+	required map[string][]string
+}
+
+// Percolator is a reverse search index.  Many queries are registered
+// up front, and then each document is matched against all of them.
+// Queries implementing RequiredTermsQuery are only executed when
+// the document contains at least one of their required terms.
+type Percolator struct {
+	s       *Sear
+	mapping Mapping
+
+	queries []*percolatorQuery // nil entries have been unregistered
+	ids     map[string]int
+	numFree int                         // nil entries in queries
+	always  []int                       // queries without required terms
+	terms   map[string]map[string][]int // field -> term -> queries
+
+	// reused between calls to Percolate
+	candidates []int
+	seen       []uint64
+	gen        uint64
+
+	// when verifying, skipped queries are executed too
+	verify bool
+	misses []string
+}
+
+// NewPercolator returns a Percolator which matches documents
+// produced by mapping.  If mapping is nil, the documents passed
+// to Percolate must implement index.Document.
+func NewPercolator(mapping Mapping) (*Percolator, error) {
+	idx, err := New(Name, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	err = idx.Open()
+	if err != nil {
+		return nil, err
+	}
+	return &Percolator{
+		s:       idx.(*Sear),
+		mapping: mapping,
+		ids:     make(map[string]int),
+		terms:   make(map[string]map[string][]int),
+	}, nil
+}
+
+// Register adds a query with the provided identifier.
+// Registering an identifier a second time replaces the
+// previously registered query.
+func (p *Percolator) Register(id string, q Query) error {
+	if q == nil {
+		return fmt.Errorf("cannot register nil query: %s", id)
+	}
+	p.Unregister(id)
+
+	pq := &percolatorQuery{
+		id:    id,
+		query: q,
+	}
+	if rtq, ok := q.(RequiredTermsQuery); ok {
+		pq.required = rtq.RequiredTerms()
+	}
+
+	num := len(p.queries)
+	p.queries = append(p.queries, pq)
+	p.seen = append(p.seen, 0)
+	p.ids[id] = num
+
+	var numRequired int
+	for field, terms := range pq.required {
+		fieldTerms, ok := p.terms[field]
+		if !ok {
+			fieldTerms = make(map[string][]int)
+			p.terms[field] = fieldTerms
+		}
+		for _, term := range terms {
+			fieldTerms[term] = append(fieldTerms[term], num)
+			numRequired++
+		}
+	}
+	if numRequired == 0 {
+		p.always = append(p.always, num)
+	}
+	return nil
+}
+
+// Unregister removes the query with the provided identifier.
+// It returns false if no such query was registered.
+func (p *Percolator) Unregister(id string) bool {
+	num, ok := p.ids[id]
+	if !ok {
+		return false
+	}
+	pq := p.queries[num]
+	for field, terms := range pq.required {
+		fieldTerms := p.terms[field]
+		for _, term := range terms {
+			fieldTerms[term] = removeQueryNum(fieldTerms[term], num)
+			if len(fieldTerms[term]) == 0 {
+				delete(fieldTerms, term)
+			}
+		}
+		if len(fieldTerms) == 0 {
+			delete(p.terms, field)
+		}
+	}
+	p.always = removeQueryNum(p.always, num)
+	p.queries[num] = nil
+	delete(p.ids, id)
+	p.numFree++
+	if p.numFree > len(p.ids) {
+		p.compact()
+	}
+	return true
+}
+
+// compact removes unregistered queries, renumbering the
+// others, so that churn does not grow the query slices
+// without bound.  The registration order is preserved.
+func (p *Percolator) compact() {
+	renumbered := make([]int, len(p.queries))
+	var next int
+	for num, pq := range p.queries {
+		if pq == nil {
+			continue
+		}
+		renumbered[num] = next
+		p.queries[next] = pq
+		p.ids[pq.id] = next
+		next++
+	}
+	clear(p.queries[next:])
+	p.queries = p.queries[:next]
+	p.seen = p.seen[:next]
+	clear(p.seen)
+	p.numFree = 0
+
+	for i, num := range p.always {
+		p.always[i] = renumbered[num]
+	}
+	for _, fieldTerms := range p.terms {
+		for _, nums := range fieldTerms {
+			for i, num := range nums {
+				nums[i] = renumbered[num]
+			}
+		}
+	}
+}
+
+func removeQueryNum(nums []int, num int) []int {
+	for i := range nums {
+		if nums[i] == num {
+			return append(nums[:i], nums[i+1:]...)
+		}
+	}
+	return nums
+}
+
+// AnalyzeTerms maps and analyzes doc, as Percolate would, and returns
+// the sorted terms of each field, keyed by field name.  Passing an
+// example document with a query's text in the queried field gives
+// the terms for RequireTerms, analyzed as the documents will be.
+func (p *Percolator) AnalyzeTerms(doc interface{}) (map[string][]string, error) {
+	idoc, err := mapDocument(p.mapping, doc)
+	if err != nil {
+		return nil, err
+	}
+	d := NewDocument()
+	d.Reset(idoc)
+	rv := make(map[string][]string, len(d.fieldNames))
+	for i, name := range d.fieldNames {
+		rv[name] = d.appendSortedTerms(rv[name], i)
+	}
+	return rv, nil
+}
+
+// SetVerify controls whether Percolate also executes the queries
+// skipped because the document has none of their required terms,
+// to find required terms which are wrong, at the cost of the
+// pre-filter's savings.  Those which match are included in the
+// results, and reported by PrefilterMisses.
+func (p *Percolator) SetVerify(verify bool) {
+	p.verify = verify
+}
+
+// PrefilterMisses returns the identifiers of the queries which, when
+// verifying, matched the last document despite lacking their required
+// terms, so would otherwise have been missed.
+func (p *Percolator) PrefilterMisses() []string {
+	return p.misses
+}
+
+// Len returns the number of registered queries.
+func (p *Percolator) Len() int {
+	return len(p.ids)
+}
+
+// Percolate indexes the provided document, replacing any previous
+// document, and returns all registered queries which match it,
+// in the order they were registered.
+func (p *Percolator) Percolate(doc interface{}) ([]PercolatorMatch, error) {
+	idoc, err := mapDocument(p.mapping, doc)
+	if err != nil {
+		return nil, err
+	}
+	err = p.s.Update(idoc)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := p.selectCandidates()
+	p.misses = p.misses[:0]
+	if p.verify {
+		candidates = candidates[:0]
+		for num, pq := range p.queries {
+			if pq != nil {
+				candidates = append(candidates, num)
+			}
+		}
+	}
+
+	ctx := context.Background()
+	var rv []PercolatorMatch
+	for _, num := range candidates {
+		pq := p.queries[num]
+		matched, score, err := pq.query.Match(ctx, p.s.reader)
+		if err != nil {
+			return nil, fmt.Errorf("error executing query %s: %v", pq.id, err)
+		}
+		if matched {
+			rv = append(rv, PercolatorMatch{
+				ID:    pq.id,
+				Score: score,
+			})
+			if p.seen[num] != p.gen {
+				p.misses = append(p.misses, pq.id)
+			}
+		}
+	}
+	return rv, nil
+}
+
+// selectCandidates returns the queries which could match the
+// current document, using the document's analyzed terms.
+func (p *Percolator) selectCandidates() []int {
+	p.gen++
+	p.candidates = append(p.candidates[:0], p.always...)
+	for _, num := range p.always {
+		p.seen[num] = p.gen
+	}
+
+	for _, d := range p.s.docs {
+		for fieldIdx, field := range d.fieldNames {
+			fieldTerms, ok := p.terms[field]
+			if !ok {
+				continue
+			}
+			for term := range d.fieldTokenFreqs[fieldIdx] {
+				for _, num := range fieldTerms[term] {
+					if p.seen[num] != p.gen {
+						p.seen[num] = p.gen
+						p.candidates = append(p.candidates, num)
+					}
+				}
+			}
+		}
+	}
+
+	sort.Ints(p.candidates)
+	return p.candidates
+}
+
+// Index returns the Sear index used by this Percolator.
+func (p *Percolator) Index() *Sear {
+	return p.s
+}
+
+// Close the Percolator and its underlying index.
+func (p *Percolator) Close() error {
+	return p.s.Close()
+}
@@ -0,0 +1,248 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//              http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sear
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	index "github.com/blevesearch/bleve_index_api"
+)
+
+// countingTermQuery records how many times it was executed
+type countingTermQuery struct {
+	testTermQuery
+	executed int
+}
+
+func (q *countingTermQuery) Match(ctx context.Context, r index.IndexReader) (bool, float64, error) {
+	q.executed++
+	return q.testTermQuery.Match(ctx, r)
+}
+
+func (q *countingTermQuery) RequiredTerms() map[string][]string {
+	return map[string][]string{q.field: {q.term}}
+}
+
+func percolatedIDs(matches []PercolatorMatch) []string {
+	var rv []string
+	for _, m := range matches {
+		rv = append(rv, m.ID)
+	}
+	return rv
+}
+
+func TestPercolator(t *testing.T) {
+	p, err := NewPercolator(MappingFunc(testMapping))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		cerr := p.Close()
+		if cerr != nil {
+			t.Fatalf("error closing percolator: %v", cerr)
+		}
+	}()
+
+	marty := &countingTermQuery{testTermQuery: testTermQuery{field: "name", term: "marty"}}
+	steve := &countingTermQuery{testTermQuery: testTermQuery{field: "name", term: "steve"}}
+	dev := &countingTermQuery{testTermQuery: testTermQuery{field: "title", term: "developer"}}
+	// does not report required terms, so always executed
+	always := &testTermQuery{field: "title", term: "developer"}
+
+	for id, q := range map[string]Query{"marty": marty, "steve": steve, "dev": dev, "always": always} {
+		err = p.Register(id, q)
+		if err != nil {
+			t.Fatalf("error registering %s: %v", id, err)
+		}
+	}
+	if p.Len() != 4 {
+		t.Errorf("expected 4 registered queries, got %d", p.Len())
+	}
+
+	matches, err := p.Percolate(map[string]string{"_id": "a", "name": "marty schoch", "title": "developer"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertAllAndOnlyValues(t, []string{"marty", "dev", "always"}, percolatedIDs(matches))
+	for _, m := range matches {
+		if m.Score <= 0 {
+			t.Errorf("expected positive score for %s, got %f", m.ID, m.Score)
+		}
+	}
+	if steve.executed != 0 {
+		t.Errorf("expected steve query not to be executed, executed %d times", steve.executed)
+	}
+
+	matches, err = p.Percolate(map[string]string{"_id": "b", "name": "steve yen", "title": "architect"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertAllAndOnlyValues(t, []string{"steve"}, percolatedIDs(matches))
+	if marty.executed != 1 || dev.executed != 1 || steve.executed != 1 {
+		t.Errorf("expected each query executed once, got marty: %d dev: %d steve: %d",
+			marty.executed, dev.executed, steve.executed)
+	}
+
+	// replace and unregister
+	err = p.Register("steve", &countingTermQuery{testTermQuery: testTermQuery{field: "name", term: "yen"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.Unregister("always") {
+		t.Errorf("expected to unregister query always")
+	}
+	if p.Unregister("always") {
+		t.Errorf("expected second unregister of query always to fail")
+	}
+	if p.Len() != 3 {
+		t.Errorf("expected 3 registered queries, got %d", p.Len())
+	}
+
+	matches, err = p.Percolate(map[string]string{"_id": "c", "name": "steve yen", "title": "developer"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual([]string{"dev", "steve"}, percolatedIDs(matches)) {
+		t.Errorf("expected matches [dev steve] in registration order, got %v", percolatedIDs(matches))
+	}
+	if steve.executed != 1 {
+		t.Errorf("expected replaced steve query not to be executed again, executed %d times", steve.executed)
+	}
+
+	err = p.Register("nil", nil)
+	if err == nil {
+		t.Errorf("expected error registering nil query")
+	}
+}
+
+func TestPercolatorChurn(t *testing.T) {
+	p, err := NewPercolator(MappingFunc(testMapping))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		cerr := p.Close()
+		if cerr != nil {
+			t.Fatalf("error closing percolator: %v", cerr)
+		}
+	}()
+
+	for _, id := range []string{"marty", "dev"} {
+		err = p.Register(id, &countingTermQuery{testTermQuery: testTermQuery{field: "name", term: "marty"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	// re-registering and churning saved searches reuses space
+	for i := 0; i < 100; i++ {
+		err = p.Register("dev", &countingTermQuery{testTermQuery: testTermQuery{field: "title", term: "developer"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = p.Register("temp", &testTermQuery{field: "name", term: "marty"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.Unregister("temp")
+	}
+	if p.Len() != 2 {
+		t.Errorf("expected 2 registered queries, got %d", p.Len())
+	}
+	if len(p.queries) > 2*p.Len()+1 || len(p.seen) != len(p.queries) {
+		t.Errorf("expected compacted queries, got %d queries and %d seen", len(p.queries), len(p.seen))
+	}
+
+	matches, err := p.Percolate(map[string]string{"_id": "a", "name": "marty schoch", "title": "developer"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual([]string{"marty", "dev"}, percolatedIDs(matches)) {
+		t.Errorf("expected matches [marty dev] in registration order, got %v", percolatedIDs(matches))
+	}
+}
+
+func TestPercolatorRequiredTerms(t *testing.T) {
+	p, err := NewPercolator(MappingFunc(testMapping))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		cerr := p.Close()
+		if cerr != nil {
+			t.Fatalf("error closing percolator: %v", cerr)
+		}
+	}()
+
+	// terms analyzed as the documents will be
+	analyzed, err := p.AnalyzeTerms(map[string]string{"_id": "q", "name": "Marty Schoch"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual([]string{"marty", "schoch"}, analyzed["name"]) {
+		t.Errorf("expected analyzed terms [marty schoch], got %v", analyzed["name"])
+	}
+
+	match := RequireTerms(&testTermQuery{field: "name", term: "marty"}, analyzed)
+	// not analyzed, so never a candidate
+	unanalyzed := RequireTerms(&testTermQuery{field: "name", term: "marty"},
+		map[string][]string{"name": {"Marty"}})
+	for id, q := range map[string]Query{"match": match, "unanalyzed": unanalyzed} {
+		err = p.Register(id, q)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	doc := map[string]string{"_id": "a", "name": "marty"}
+	matches, err := p.Percolate(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertAllAndOnlyValues(t, []string{"match"}, percolatedIDs(matches))
+	if len(p.PrefilterMisses()) != 0 {
+		t.Errorf("expected no prefilter misses without verifying, got %v", p.PrefilterMisses())
+	}
+
+	p.SetVerify(true)
+	matches, err = p.Percolate(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertAllAndOnlyValues(t, []string{"match", "unanalyzed"}, percolatedIDs(matches))
+	if !reflect.DeepEqual([]string{"unanalyzed"}, p.PrefilterMisses()) {
+		t.Errorf("expected prefilter miss of unanalyzed, got %v", p.PrefilterMisses())
+	}
+}
+
+func TestRequiredTermsCombinations(t *testing.T) {
+	a := map[string][]string{"name": {"marty", "steve"}}
+	b := map[string][]string{"title": {"developer"}}
+
+	if got := ConjunctionRequiredTerms(a, nil, b); !reflect.DeepEqual(b, got) {
+		t.Errorf("expected conjunction to require %v, got %v", b, got)
+	}
+	if got := ConjunctionRequiredTerms(nil, nil); len(got) != 0 {
+		t.Errorf("expected conjunction without requirements to require nothing, got %v", got)
+	}
+	expected := map[string][]string{"name": {"marty", "steve"}, "title": {"developer"}}
+	if got := DisjunctionRequiredTerms(a, b); !reflect.DeepEqual(expected, got) {
+		t.Errorf("expected disjunction to require %v, got %v", expected, got)
+	}
+	if got := DisjunctionRequiredTerms(a, nil); len(got) != 0 {
+		t.Errorf("expected disjunction with an unrestricted query to require nothing, got %v", got)
+	}
+}
@@ -0,0 +1,134 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//              http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sear
+
+import (
+	"fmt"
+	"sync"
+)
+
+// MatchResult is the outcome of matching a single document.
+type MatchResult struct {
+	Matched bool
+	Score   float64
+	Err     error
+}
+
+// MatcherPool hands out Matchers to concurrent goroutines.
+// Each Matcher owns its own Sear index and Reader, while the
+// mapping, the query and all compiled automata are shared.
+// The mapping and query must therefore be safe for concurrent
+// use, which is true of bleve mappings and queries.
+type MatcherPool struct {
+	automata *AutomatonCache
+	matchers chan *Matcher
+	all      []*Matcher
+}
+
+// NewMatcherPool returns a pool of size Matchers, all evaluating
+// query against documents produced by mapping.
+func NewMatcherPool(size int, mapping Mapping, query Query) (*MatcherPool, error) {
+	if size < 1 {
+		return nil, fmt.Errorf("matcher pool size must be at least 1, got %d", size)
+	}
+	rv := &MatcherPool{
+		automata: NewAutomatonCacheWithCapacity(DefaultAutomatonCacheSize),
+		matchers: make(chan *Matcher, size),
+	}
+	for i := 0; i < size; i++ {
+		m, err := NewMatcher(mapping, query)
+		if err != nil {
+			_ = rv.Close()
+			return nil, err
+		}
+		m.s.SetAutomatonCache(rv.automata)
+		rv.all = append(rv.all, m)
+		rv.matchers <- m
+	}
+	return rv, nil
+}
+
+// Get returns a Matcher for exclusive use by the calling goroutine,
+// blocking until one is available.  It must be returned with Put.
+func (p *MatcherPool) Get() *Matcher {
+	return <-p.matchers
+}
+
+// Put returns a Matcher obtained from Get to the pool.
+func (p *MatcherPool) Put(m *Matcher) {
+	p.matchers <- m
+}
+
+// AutomatonCache returns the cache of automata shared by the pool's
+// Matchers, with capacity DefaultAutomatonCacheSize, unless changed
+// with SetCapacity.
+func (p *MatcherPool) AutomatonCache() *AutomatonCache {
+	return p.automata
+}
+
+// Size returns the number of Matchers in the pool.
+func (p *MatcherPool) Size() int {
+	return len(p.all)
+}
+
+// MatchAll matches the provided documents concurrently, using
+// as many Matchers as are available.  The results are in the
+// same order as the documents.  Matchers held by other goroutines
+// are not waited for, unless none are available.
+func (p *MatcherPool) MatchAll(docs []interface{}) []MatchResult {
+	rv := make([]MatchResult, len(docs))
+
+	workers := len(p.all)
+	if workers > len(docs) {
+		workers = len(docs)
+	}
+
+	work := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			// take the document first, so that workers which cannot
+			// get a Matcher do not hold up the others
+			for n := range work {
+				m := p.Get()
+				r := &rv[n]
+				r.Matched, r.Score, r.Err = m.Match(docs[n])
+				p.Put(m)
+			}
+		}()
+	}
+	for n := range docs {
+		work <- n
+	}
+	close(work)
+	wg.Wait()
+
+	return rv
+}
+
+// Close the pool and all of its Matchers.
+// The pool must not be used after it is closed.
+func (p *MatcherPool) Close() error {
+	var rv error
+	for _, m := range p.all {
+		err := m.Close()
+		if err != nil && rv == nil {
+			rv = err
+		}
+	}
+	return rv
+}
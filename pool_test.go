@@ -0,0 +1,272 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//              http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sear
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	index "github.com/blevesearch/bleve_index_api"
+)
+
+// testRegexpQuery is a minimal stand-in for a bleve regexp query
+type testRegexpQuery struct {
+	field  string
+	regexp string
+}
+
+func (q *testRegexpQuery) Match(ctx context.Context, r index.IndexReader) (bool, float64, error) {
+	fd, err := r.(index.IndexReaderRegexp).FieldDictRegexp(q.field, q.regexp)
+	if err != nil {
+		return false, 0, err
+	}
+	var score float64
+	next, err := fd.Next()
+	for err == nil && next != nil {
+		score++
+		next, err = fd.Next()
+	}
+	return score > 0, score, err
+}
+
+func TestMatcherPool(t *testing.T) {
+	_, err := NewMatcherPool(0, MappingFunc(testMapping), &testRegexpQuery{field: "name", regexp: "ma.*"})
+	if err == nil {
+		t.Errorf("expected error creating empty pool")
+	}
+
+	pool, err := NewMatcherPool(4, MappingFunc(testMapping), &testRegexpQuery{field: "name", regexp: "ma.*"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		cerr := pool.Close()
+		if cerr != nil {
+			t.Fatalf("error closing pool: %v", cerr)
+		}
+	}()
+	if pool.Size() != 4 {
+		t.Errorf("expected pool size 4, got %d", pool.Size())
+	}
+
+	var docs []interface{}
+	for i := 0; i < 100; i++ {
+		name := "steve"
+		if i%3 == 0 {
+			name = "marty mark"
+		}
+		docs = append(docs, map[string]string{"_id": fmt.Sprintf("%d", i), "name": name})
+	}
+	// unmappable document reports its own error
+	docs = append(docs, "invalid")
+
+	results := pool.MatchAll(docs)
+	if len(results) != len(docs) {
+		t.Fatalf("expected %d results, got %d", len(docs), len(results))
+	}
+	for i, res := range results[:100] {
+		if res.Err != nil {
+			t.Fatalf("unexpected error for doc %d: %v", i, res.Err)
+		}
+		if res.Matched != (i%3 == 0) {
+			t.Errorf("expected doc %d matched %t, got %t", i, i%3 == 0, res.Matched)
+		}
+		if res.Matched && res.Score != 2 {
+			t.Errorf("expected doc %d score 2, got %f", i, res.Score)
+		}
+	}
+	if results[100].Err == nil {
+		t.Errorf("expected error for unmappable document")
+	}
+
+	if stats := pool.AutomatonCache().Stats(); stats.RegexpEntries != 1 {
+		t.Errorf("expected 1 shared compiled regexp, got %d", stats.RegexpEntries)
+	}
+
+	// concurrent use of Get/Put
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m := pool.Get()
+			defer pool.Put(m)
+			matched, _, err := m.Match(map[string]string{"_id": "x", "name": "mary"})
+			if err != nil || !matched {
+				t.Errorf("expected match, got %t, err: %v", matched, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	results = pool.MatchAll(nil)
+	if len(results) != 0 {
+		t.Errorf("expected no results for no documents, got %d", len(results))
+	}
+
+	// all but one Matcher held by the caller
+	var held []*Matcher
+	for i := 0; i < pool.Size()-1; i++ {
+		held = append(held, pool.Get())
+	}
+	results = pool.MatchAll(docs[:10])
+	for i, res := range results {
+		if res.Err != nil || res.Matched != (i%3 == 0) {
+			t.Errorf("expected doc %d matched %t, got %t, err: %v", i, i%3 == 0, res.Matched, res.Err)
+		}
+	}
+	for _, m := range held {
+		pool.Put(m)
+	}
+}
+
+func TestAutomatonCache(t *testing.T) {
+	c := NewAutomatonCache()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			re, err := c.Regexp("li.*")
+			if err != nil || re == nil {
+				t.Errorf("expected compiled regexp, err: %v", err)
+			}
+			la, err := c.LevenshteinAutomaton("gas", 1)
+			if err != nil || la == nil {
+				t.Errorf("expected levenshtein automaton, err: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if c.regexps.Len() != 1 || c.levs.Len() != 1 {
+		t.Errorf("expected 1 regexp and 1 levenshtein automaton, got %d and %d", c.regexps.Len(), c.levs.Len())
+	}
+
+	_, err := c.Regexp("[")
+	if err == nil {
+		t.Errorf("expected error compiling invalid regexp")
+	}
+	_, err = c.LevenshteinAutomaton("gas", 5)
+	if err == nil {
+		t.Errorf("expected error building automaton with excessive fuzziness")
+	}
+
+	// the same automata are used by readers sharing the cache
+	idx, err := New("", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := idx.(*Sear)
+	s.SetAutomatonCache(c)
+	mapAndUpdateDocument(t, idx, "a", map[string]interface{}{
+		"name": "liquid gap",
+	})
+	reader, _ := idx.Reader()
+	_, ra, err := reader.(index.IndexReaderRegexp).FieldDictRegexpAutomaton("name", "li.*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cached, _ := c.Regexp("li.*")
+	if ra != cached {
+		t.Errorf("expected reader to use shared regexp")
+	}
+	fd, _, err := reader.(index.IndexReaderFuzzy).FieldDictFuzzyAutomaton("name", "gas", 1, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertTermDictionary(t, fd, []string{"gap"})
+}
+
+func TestSharedAutomatonCache(t *testing.T) {
+	c := NewAutomatonCacheWithCapacity(1)
+	for _, re := range []string{"a.*", "b.*", "b.*"} {
+		_, err := c.Regexp(re)
+		if err != nil {
+			t.Fatalf("error compiling regexp: %v", err)
+		}
+	}
+	_, err := c.LevenshteinAutomaton("marty", 1)
+	if err != nil {
+		t.Fatalf("error building automaton: %v", err)
+	}
+	stats := c.Stats()
+	expected := AutomatonCacheStats{
+		RegexpHits: 1, RegexpMisses: 2, RegexpEntries: 1,
+		LevenshteinMisses: 1, LevenshteinEntries: 1,
+	}
+	if stats != expected {
+		t.Errorf("expected automaton cache stats %+v, got %+v", expected, stats)
+	}
+
+	// growing keeps the entries, shrinking evicts the least recently used
+	c.SetCapacity(2)
+	for _, re := range []string{"a.*", "b.*"} {
+		_, err = c.Regexp(re)
+		if err != nil {
+			t.Fatalf("error compiling regexp: %v", err)
+		}
+	}
+	if stats = c.Stats(); stats.RegexpEntries != 2 || stats.RegexpHits != 2 {
+		t.Errorf("expected 2 regexps and 2 hits, got %+v", stats)
+	}
+	c.SetCapacity(1)
+	if stats = c.Stats(); stats.RegexpEntries != 1 || stats.LevenshteinEntries != 1 {
+		t.Errorf("expected 1 regexp and 1 Levenshtein automaton, got %+v", stats)
+	}
+	_, err = c.Regexp("b.*")
+	if err != nil {
+		t.Fatalf("error compiling regexp: %v", err)
+	}
+	if stats = c.Stats(); stats.RegexpHits != 3 {
+		t.Errorf("expected most recently used regexp to be kept, got %+v", stats)
+	}
+
+	// indexes configured to share the process-wide cache compile once
+	shared := SharedAutomatonCache()
+	if shared != SharedAutomatonCache() {
+		t.Fatalf("expected the same shared cache")
+	}
+	before := shared.Stats()
+	for i := 0; i < 3; i++ {
+		idx, err := New("", map[string]interface{}{
+			ConfigSharedAutomatonCache: true,
+		}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mapAndUpdateDocument(t, idx, "a", map[string]interface{}{
+			"name": "marty",
+		})
+		reader, err := idx.Reader()
+		if err != nil {
+			t.Fatalf("error getting index reader: %v", err)
+		}
+		fd, err := reader.(index.IndexReaderRegexp).FieldDictRegexp("name", "shared-m.*")
+		if err != nil {
+			t.Fatalf("error getting field dict regexp: %v", err)
+		}
+		assertTermDictionaryEmpty(t, fd)
+	}
+	// the regexp may already be cached, when the test is repeated
+	after := shared.Stats()
+	if after.RegexpMisses-before.RegexpMisses > 1 || after.RegexpHits-before.RegexpHits < 2 {
+		t.Errorf("expected at most 1 miss and at least 2 hits, got %d and %d",
+			after.RegexpMisses-before.RegexpMisses, after.RegexpHits-before.RegexpHits)
+	}
+}
@@ -15,37 +15,53 @@
 package sear
 
 import (
-	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"maps"
 	"sort"
 	"strings"
 
 	index "github.com/blevesearch/bleve_index_api"
 	"github.com/blevesearch/vellum"
 	vellev "github.com/blevesearch/vellum/levenshtein"
-	velreg "github.com/blevesearch/vellum/regexp"
 )
 
-var internalDocID = []byte{0}
+// ErrDocumentNotFound is returned when requesting
+// a document which is not in the index.
+var ErrDocumentNotFound = errors.New("document not found")
 
 // Reader is responsible for reading the index data
 // It is also responsible for caching some portions
 // of a read operation which can be used for subsequent
 // reads.
 type Reader struct {
-	s *Sear
-
-	velregCache map[string]*velreg.Regexp
-	levSlice    []int
+	s  *Sear
+	ds *docSet
+
+	// snapshot readers have a private copy of the docSet and of the
+	// internal values, and keep the corpus stats provider in use when
+	// they were obtained, so they may be used on another goroutine
+	isSnapshot bool
+	internal   map[string][]byte
+	corpus     CorpusStats
+
+	// nil for snapshot readers, which only use the automaton cache
+	velregCache *lruCache[string, *compiledRegexp]
+	levCache    *lruCache[levKey, vellum.Automaton]
+
+	// optional, shared with other readers,
+	// always set for snapshot readers
+	automata *AutomatonCache
 }
 
 // NewReader returns a new reader for the provided Sear instance.
 func NewReader(m *Sear) *Reader {
 	rv := &Reader{
 		s:           m,
-		velregCache: make(map[string]*velreg.Regexp),
-		levSlice:    make([]int, 64),
+		ds:          &m.docSet,
+		velregCache: newLRUCache[string, *compiledRegexp](m.config.regexpCacheSize),
+		levCache:    newLRUCache[levKey, vellum.Automaton](m.config.levCacheSize),
 	}
 
 	return rv
@@ -53,58 +69,92 @@ func NewReader(m *Sear) *Reader {
 
 func (r *Reader) TermFieldReader(ctx context.Context, term []byte, field string, includeFreq, includeNorm,
 	includeTermVectors bool) (index.TermFieldReader, error) {
-	if r.s.doc == nil {
-		return termFieldReaderEmpty, nil
+	r.s.stats.termLookup()
+	includeTermVectors = includeTermVectors && r.s.config.trackTermVectors
+	var rv *TermFieldReader
+	for num, d := range r.ds.docs {
+		atf, l, err := d.TokenFreqsAndLen(field)
+		if err != nil {
+			// only error is field doesn't exist in doc
+			continue
+		}
+		tf, ok := atf[string(term)]
+		if !ok {
+			continue
+		}
+		if rv == nil {
+			rv = newTermFieldReader(includeFreq, includeNorm, includeTermVectors)
+		}
+		rv.addPosting(num, tf, l)
 	}
-	atf, l, err := r.s.doc.TokenFreqsAndLen(field)
-	if err != nil {
-		// only error is field doesn't exist in doc
+	if rv == nil {
 		return termFieldReaderEmpty, nil
 	}
-	tf, ok := atf[string(term)]
-	if !ok {
-		return termFieldReaderEmpty, nil
+	if corpus := r.corpusStats(); corpus != nil {
+		rv.count = corpus.DocFreq(field, string(term))
+		rv.hasCount = true
 	}
 
-	return NewTermFieldReaderFromTokenFreqAndLen(tf, l, includeFreq, includeNorm, includeTermVectors), nil
+	return rv, nil
 }
 
 func (r *Reader) DocIDReaderAll() (index.DocIDReader, error) {
-	if r.s.doc == nil {
+	if len(r.ds.docs) == 0 {
 		return docIDReaderEmpty, nil
 	}
-	return NewDocIDReader(), nil
+	return NewDocIDReaderWithIDs(internalDocIDs[:len(r.ds.docs)]), nil
 }
 
 func (r *Reader) DocIDReaderOnly(ids []string) (index.DocIDReader, error) {
-	if r.s.doc == nil {
+	var rv []index.IndexInternalID
+	for num, d := range r.ds.docs {
+		for _, id := range ids {
+			if id == d.doc.ID() {
+				rv = append(rv, internalDocIDs[num])
+				break
+			}
+		}
+	}
+	if len(rv) == 0 {
 		return docIDReaderEmpty, nil
 	}
-	for _, id := range ids {
-		if id == r.s.doc.doc.ID() {
-			return NewDocIDReader(), nil
+	return NewDocIDReaderWithIDs(rv), nil
+}
+
+// newFieldDict returns a dictionary over the provided terms, in
+// multi-document mode the entry counts reflect the document frequency,
+// with corpus stats configured they reflect the corpus
+func (r *Reader) newFieldDict(field string, terms []string, include func(string) bool) *FieldDict {
+	rv := NewFieldDictWithTerms(terms, include)
+	if corpus := r.corpusStats(); corpus != nil {
+		rv.count = func(term string) uint64 {
+			return corpus.DocFreq(field, term)
+		}
+	} else if len(r.ds.docs) > 1 {
+		rv.count = func(term string) uint64 {
+			return r.ds.docFreq(field, term)
 		}
 	}
-	return docIDReaderEmpty, nil
+	return rv
 }
 
 func (r *Reader) FieldDict(field string) (index.FieldDict, error) {
-	if r.s.doc == nil {
+	if len(r.ds.docs) == 0 {
 		return fieldDictEmpty, nil
 	}
-	fieldSortedTerms, err := r.s.doc.SortedTermsForField(field)
+	fieldSortedTerms, err := r.ds.SortedTermsForField(field)
 	if err != nil {
 		// only error is field doesn't exist in doc
 		return fieldDictEmpty, nil
 	}
-	return NewFieldDictWithTerms(fieldSortedTerms, nil), nil
+	return r.newFieldDict(field, fieldSortedTerms, nil), nil
 }
 
 func (r *Reader) FieldDictRange(field string, startTerm, endTerm []byte) (index.FieldDict, error) {
-	if r.s.doc == nil {
+	if len(r.ds.docs) == 0 {
 		return fieldDictEmpty, nil
 	}
-	fieldSortedTerms, err := r.s.doc.SortedTermsForField(field)
+	fieldSortedTerms, err := r.ds.SortedTermsForField(field)
 	if err != nil {
 		// only error is field doesn't exist in doc
 		return fieldDictEmpty, nil
@@ -117,25 +167,34 @@ func (r *Reader) FieldDictRange(field string, startTerm, endTerm []byte) (index.
 	if endIdx < len(fieldSortedTerms) && fieldSortedTerms[endIdx] == endTermStr {
 		endIdx++
 	}
-	return NewFieldDictWithTerms(fieldSortedTerms[startIdx:endIdx], nil), nil
+	return r.newFieldDict(field, fieldSortedTerms[startIdx:endIdx], nil), nil
 }
 
 func (r *Reader) FieldDictPrefix(field string, termPrefix []byte) (index.FieldDict, error) {
-	if r.s.doc == nil {
+	if len(r.ds.docs) == 0 {
 		return fieldDictEmpty, nil
 	}
-	fieldSortedTerms, err := r.s.doc.SortedTermsForField(field)
+	fieldSortedTerms, err := r.ds.SortedTermsForField(field)
 	if err != nil {
 		// only error is field doesn't exist in doc
 		return fieldDictEmpty, nil
 	}
 	prefixStr := string(termPrefix)
-	startIdx := sort.SearchStrings(fieldSortedTerms, prefixStr)
-	rest := fieldSortedTerms[startIdx:]
+	return r.newFieldDict(field, termsWithPrefix(fieldSortedTerms, prefixStr), fieldDictPrefix(prefixStr)), nil
+}
+
+// termsWithPrefix returns the sub-slice of the sorted terms
+// which start with prefix
+func termsWithPrefix(sortedTerms []string, prefix string) []string {
+	if prefix == "" {
+		return sortedTerms
+	}
+	startIdx := sort.SearchStrings(sortedTerms, prefix)
+	rest := sortedTerms[startIdx:]
 	endIdx := sort.Search(len(rest), func(i int) bool {
-		return !strings.HasPrefix(rest[i], prefixStr)
+		return !strings.HasPrefix(rest[i], prefix)
 	})
-	return NewFieldDictWithTerms(rest[:endIdx], fieldDictPrefix(prefixStr)), nil
+	return rest[:endIdx]
 }
 
 func automatonMatch(la vellum.Automaton, termStr string) bool {
@@ -161,82 +220,152 @@ func (r *Reader) FieldDictRegexpAutomaton(field, regexStr string) (
 
 func (r *Reader) fieldDictRegexp(field, regexStr string) (
 	index.FieldDict, index.RegexAutomaton, error) {
-	regex, cached := r.velregCache[regexStr]
-	if !cached {
-		var err error
-		regex, err = velreg.New(regexStr)
-		if err != nil {
-			return nil, nil, fmt.Errorf("error compiling regexp: %v", err)
-		}
-		r.velregCache[regexStr] = regex
+	err := r.checkRegexpLength(regexStr)
+	if err != nil {
+		return nil, nil, err
 	}
-	if r.s.doc == nil {
+	var cr *compiledRegexp
+	if r.velregCache == nil {
+		cr = r.compileRegexp(regexStr)
+	} else if cached, ok := r.velregCache.Get(regexStr); ok {
+		r.s.stats.regexpCacheHit()
+		cr = cached
+	} else {
+		r.s.stats.regexpCacheMiss()
+		cr = r.compileRegexp(regexStr)
+		r.velregCache.Put(regexStr, cr)
+	}
+	if cr.err != nil {
+		return nil, nil, cr.err
+	}
+	regex := cr.regex
+	if len(r.ds.docs) == 0 {
 		return fieldDictEmpty, regex, nil
 	}
-	fieldSortedTerms, err := r.s.doc.SortedTermsForField(field)
+	fieldSortedTerms, err := r.ds.SortedTermsForField(field)
 	if err != nil {
 		// only error is field doesn't exist in doc
 		return fieldDictEmpty, regex, nil
 	}
-	return NewFieldDictWithTerms(fieldSortedTerms, func(s string) bool {
+	candidates := termsWithPrefix(fieldSortedTerms, cr.prefix)
+	return r.newFieldDict(field, candidates, func(s string) bool {
 		return automatonMatch(regex, s)
 	}), regex, nil
 }
 
 func (r *Reader) FieldDictFuzzy(field, term string, fuzziness int, prefix string) (
 	index.FieldDict, error) {
-	if r.s.doc == nil {
-		return fieldDictEmpty, nil
+	fd, _, err := r.fieldDictFuzzy(field, term, fuzziness, prefix)
+	return fd, err
+}
+
+func (r *Reader) FieldDictFuzzyAutomaton(field, term string, fuzziness int, prefix string) (
+	index.FieldDict, index.FuzzyAutomaton, error) {
+	return r.fieldDictFuzzy(field, term, fuzziness, prefix)
+}
+
+// fieldDictFuzzy filters the dictionary with the automaton it returns,
+// so they always agree, and each entry has the term's edit distance
+func (r *Reader) fieldDictFuzzy(field, term string, fuzziness int, prefix string) (
+	index.FieldDict, index.FuzzyAutomaton, error) {
+	if fuzziness > r.s.config.maxFuzziness {
+		return nil, nil, fmt.Errorf("fuzziness %d exceeds the max limit %d", fuzziness, r.s.config.maxFuzziness)
+	}
+	fa, err := r.fuzzyAutomaton(term, fuzziness)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(r.ds.docs) == 0 {
+		return fieldDictEmpty, fa, nil
 	}
-	fieldSortedTerms, err := r.s.doc.SortedTermsForField(field)
+	fieldSortedTerms, err := r.ds.SortedTermsForField(field)
 	if err != nil {
 		// only error is field doesn't exist in doc
-		return fieldDictEmpty, nil
+		return fieldDictEmpty, fa, nil
 	}
-	return NewFieldDictWithTerms(fieldSortedTerms, func(indexTerm string) bool {
-		var dist int
-		var exceeded bool
-		dist, exceeded, r.levSlice = levenshteinDistanceMaxReuseSlice(
-			term, indexTerm, fuzziness, r.levSlice)
-		if dist <= fuzziness && !exceeded {
-			return true
-		}
-		return false
-	}), nil
+	// like scorch, candidates must start with the prefix, and be
+	// within fuzziness of the whole term (including the prefix)
+	candidates := termsWithPrefix(fieldSortedTerms, prefix)
+	rv := r.newFieldDict(field, candidates, nil)
+	rv.fuzzy = func(indexTerm string) (bool, uint8) {
+		r.s.stats.fuzzyEvaluation()
+		return fa.MatchAndDistance(indexTerm)
+	}
+	return rv, fa, nil
 }
 
-func (r *Reader) FieldDictFuzzyAutomaton(field, term string, fuzziness int, prefix string) (
-	index.FieldDict, index.FuzzyAutomaton, error) {
-	a, err := getLevAutomaton(term, uint8(fuzziness))
+// fuzzyAutomaton returns the automaton matching terms within
+// fuzziness of term, as used by scorch, or beyond the fuzziness
+// supported by vellum, one computing the distances directly
+func (r *Reader) fuzzyAutomaton(term string, fuzziness int) (index.FuzzyAutomaton, error) {
+	if fuzziness > maxAutomatonFuzziness {
+		return newLevenshteinMatcher(term, fuzziness), nil
+	}
+	a, err := r.levAutomaton(term, uint8(fuzziness))
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
-	var fa index.FuzzyAutomaton
-	if vfa, ok := a.(vellum.FuzzyAutomaton); ok {
-		fa = vfa
+	if fa, ok := a.(vellum.FuzzyAutomaton); ok {
+		return fa, nil
 	}
+	return newLevenshteinMatcher(term, fuzziness), nil
+}
 
-	fd, err := r.FieldDictFuzzy(field, term, fuzziness, prefix)
-	return fd, fa, err
+// levAutomaton returns the Levenshtein automaton for term and
+// fuzziness, building it only if it is not already cached
+func (r *Reader) levAutomaton(term string, fuzziness uint8) (vellum.Automaton, error) {
+	if r.levCache == nil {
+		return r.automata.LevenshteinAutomaton(term, fuzziness)
+	}
+	key := levKey{term: term, fuzziness: fuzziness}
+	a, cached := r.levCache.Get(key)
+	if cached {
+		r.s.stats.levCacheHit()
+		return a, nil
+	}
+	r.s.stats.levCacheMiss()
+	var err error
+	if r.automata != nil {
+		a, err = r.automata.LevenshteinAutomaton(term, fuzziness)
+	} else {
+		a, err = getLevAutomaton(term, fuzziness)
+	}
+	if err != nil {
+		return nil, err
+	}
+	r.levCache.Put(key, a)
+	return a, nil
 }
 
 func (r *Reader) FieldDictContains(field string) (index.FieldDictContains, error) {
-	if r.s.doc == nil {
-		return fieldDictContainsEmpty, nil
+	var rv *FieldDictContains
+	for _, d := range r.ds.docs {
+		atf, _, err := d.TokenFreqsAndLen(field)
+		if err != nil {
+			// only error is field doesn't exist in doc
+			continue
+		}
+		if rv == nil {
+			rv = NewFieldDictContainsFromTokenFrequencies(atf)
+		} else {
+			rv.atfs = append(rv.atfs, atf)
+		}
 	}
-	atf, _, err := r.s.doc.TokenFreqsAndLen(field)
-	if err != nil {
-		// only error is field doesn't exist in doc
+	if rv == nil {
 		return fieldDictContainsEmpty, nil
 	}
-	return NewFieldDictContainsFromTokenFrequencies(atf), nil
+	return rv, nil
 }
 
+// Document returns the indexed document with the provided id,
+// only its stored fields are visited.
 func (r *Reader) Document(id string) (index.Document, error) {
-	if r.s.doc.doc.ID() == id {
-		return r.s.doc.doc, nil
+	for _, d := range r.ds.docs {
+		if d.doc.ID() == id {
+			return d.StoredDocument(), nil
+		}
 	}
-	return nil, fmt.Errorf("document not found")
+	return nil, ErrDocumentNotFound
 }
 
 func (r *Reader) DocValueReader(fields []string) (index.DocValueReader, error) {
@@ -247,38 +376,101 @@ func (r *Reader) DocValueReader(fields []string) (index.DocValueReader, error) {
 }
 
 func (r *Reader) Fields() ([]string, error) {
-	if r.s.doc != nil {
-		return r.s.doc.Fields(), nil
+	switch len(r.ds.docs) {
+	case 0:
+		return nil, nil
+	case 1:
+		return r.ds.docs[0].Fields(), nil
+	}
+	var rv []string
+	seen := make(map[string]struct{})
+	for _, d := range r.ds.docs {
+		for _, field := range d.Fields() {
+			if _, ok := seen[field]; !ok {
+				seen[field] = struct{}{}
+				rv = append(rv, field)
+			}
+		}
 	}
-	return nil, nil
+	return rv, nil
 }
 
 func (r *Reader) GetInternal(key []byte) ([]byte, error) {
+	if r.isSnapshot {
+		return r.internal[string(key)], nil
+	}
 	return r.s.internal[string(key)], nil
 }
 
+// DocCount returns the number of documents indexed,
+// or with corpus stats configured, in the corpus.
 func (r *Reader) DocCount() (uint64, error) {
-	if r.s.doc != nil {
-		return 1, nil
+	if corpus := r.corpusStats(); corpus != nil {
+		return corpus.DocCount(), nil
 	}
-	return 0, nil
+	return uint64(len(r.ds.docs)), nil
 }
 
 func (r *Reader) ExternalID(id index.IndexInternalID) (string, error) {
-	if bytes.Equal(id, internalDocID) {
-		return r.s.doc.doc.ID(), nil
+	num, ok := docNumForInternalID(id)
+	if ok && num < len(r.ds.docs) {
+		return r.ds.docs[num].doc.ID(), nil
 	}
 	return "", fmt.Errorf("no such document with internal id: '%v'", id)
 }
 
 func (r *Reader) InternalID(id string) (index.IndexInternalID, error) {
-	if id == r.s.doc.doc.ID() {
-		return internalDocID, nil
+	for num, d := range r.ds.docs {
+		if id == d.doc.ID() {
+			return internalDocIDs[num], nil
+		}
 	}
 	return nil, fmt.Errorf("no such document with external id: %s", id)
 }
 
+// snapshot returns a reader isolated from subsequent changes to
+// the index.  No document analysis is copied, instead the documents
+// are pinned, and the index will not reuse them until it is closed.
+// Automata are compiled through the index's automaton cache, which
+// is goroutine-safe, so they are shared by all its snapshots.
+func (r *Reader) snapshot() *Reader {
+	automata := r.automata
+	if automata == nil {
+		automata = r.s.snapshotAutomata()
+	}
+	rv := &Reader{
+		s: r.s,
+		ds: &docSet{
+			docs:        append([]*Document(nil), r.ds.docs...),
+			sortedTerms: make(map[string][]string),
+			isolated:    true,
+		},
+		isSnapshot: true,
+		internal:   maps.Clone(r.s.internal),
+		corpus:     r.s.corpus,
+		automata:   automata,
+	}
+	for _, d := range rv.ds.docs {
+		d.snapshots.Add(1)
+	}
+	return rv
+}
+
+// corpusStats returns the corpus stats in use, if any
+func (r *Reader) corpusStats() CorpusStats {
+	if r.isSnapshot {
+		return r.corpus
+	}
+	return r.s.corpus
+}
+
 func (r *Reader) Close() error {
+	if r.isSnapshot {
+		for _, d := range r.ds.docs {
+			d.snapshots.Add(-1)
+		}
+		r.ds.docs = nil
+	}
 	return nil
 }
 
@@ -0,0 +1,140 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//              http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sear
+
+import (
+	"errors"
+	"fmt"
+	"regexp/syntax"
+	"sort"
+
+	velreg "github.com/blevesearch/vellum/regexp"
+)
+
+// RegexpLimitError is returned by FieldDictRegexp and
+// FieldDictRegexpAutomaton for regexps which exceed a limit.
+type RegexpLimitError struct {
+	Regexp string
+	Limit  string // the config key of the limit exceeded
+	Max    int
+}
+
+func (e *RegexpLimitError) Error() string {
+	return fmt.Sprintf("regexp '%s' exceeds %s of %d", e.Regexp, e.Limit, e.Max)
+}
+
+// compiledRegexp is a regexp as cached by the reader and the
+// AutomatonCache, with the literal prefix of every term it matches,
+// used to only evaluate the regexp for terms with that prefix.  Regexps which could not
+// be compiled, including those exceeding the limits, are cached
+// with their error, so that they are only compiled once.
+type compiledRegexp struct {
+	regex  *velreg.Regexp
+	prefix string
+	err    error
+}
+
+// checkRegexpLength returns an error for regexps longer than the
+// limit, before they are compiled or used as cache keys
+func (r *Reader) checkRegexpLength(regexStr string) error {
+	maxLen := r.s.config.regexpMaxPatternLength
+	if maxLen > 0 && len(regexStr) > maxLen {
+		return &RegexpLimitError{
+			Regexp: regexStr,
+			Limit:  ConfigRegexpMaxPatternLength,
+			Max:    maxLen,
+		}
+	}
+	return nil
+}
+
+// compileRegexp compiles regexStr, through the automaton cache
+// if there is one, within the reader's limits.
+func (r *Reader) compileRegexp(regexStr string) *compiledRegexp {
+	maxStates := r.s.config.regexpMaxDFAStates
+	if r.automata != nil {
+		return r.automata.compiledRegexp(regexStr, maxStates)
+	}
+	return newCompiledRegexp(regexStr, maxStates)
+}
+
+// newCompiledRegexp compiles regexStr within maxStates,
+// and extracts its literal prefix
+func newCompiledRegexp(regexStr string, maxStates int) *compiledRegexp {
+	regex, err := newRegexpWithLimit(regexStr, maxStates)
+	if err != nil {
+		return &compiledRegexp{err: err}
+	}
+	return &compiledRegexp{
+		regex:  regex,
+		prefix: regexpLiteralPrefix(regexStr),
+	}
+}
+
+// newRegexpWithLimit compiles regexStr, returning a RegexpLimitError if
+// its DFA has more than maxStates states.  vellum limits the size of the
+// compiled program, which is set in proportion to maxStates, as vellum's
+// own defaults are, so that most regexps exceeding a low limit are
+// rejected before their DFA is built.  Others are built up to vellum's
+// own limit on states, and then checked.
+func newRegexpWithLimit(regexStr string, maxStates int) (*velreg.Regexp, error) {
+	regex, err := velreg.NewWithLimit(regexStr, regexpCompiledSizeLimit(maxStates))
+	if errors.Is(err, velreg.ErrCompiledTooBig) || errors.Is(err, velreg.ErrTooManyStates) ||
+		(err == nil && regexpNumStates(regex) > maxStates) {
+		return nil, &RegexpLimitError{
+			Regexp: regexStr,
+			Limit:  ConfigRegexpMaxDFAStates,
+			Max:    maxStates,
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error compiling regexp: %v", err)
+	}
+	return regex, nil
+}
+
+// regexpCompiledSizeLimit returns the limit on the size of the
+// compiled program of a regexp, for a limit on its DFA states
+func regexpCompiledSizeLimit(maxStates int) uint {
+	if maxStates >= velreg.StateLimit {
+		return velreg.DefaultLimit
+	}
+	return uint(maxStates) * (velreg.DefaultLimit / velreg.StateLimit)
+}
+
+// regexpLiteralPrefix returns the literal string which every match of
+// the regexp starts with, parsing it as vellum does, or "" if there is
+// none or it cannot be determined.
+func regexpLiteralPrefix(regexStr string) string {
+	parsed, err := syntax.Parse(regexStr, syntax.Perl)
+	if err != nil {
+		return ""
+	}
+	prog, err := syntax.Compile(parsed.Simplify())
+	if err != nil {
+		return ""
+	}
+	prefix, _ := prog.Prefix()
+	return prefix
+}
+
+// regexpNumStates returns the number of states in the regexp's DFA,
+// which vellum numbers from 1, reporting that every state can match,
+// and that no state beyond the last can (see TestRegexpNumStatesVellum).
+func regexpNumStates(regex *velreg.Regexp) int {
+	return sort.Search(velreg.StateLimit+1, func(s int) bool {
+		return s > 0 && !regex.CanMatch(s)
+	}) - 1
+}
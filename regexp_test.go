@@ -0,0 +1,287 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//              http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sear
+
+import (
+	"errors"
+	"testing"
+
+	index "github.com/blevesearch/bleve_index_api"
+	velreg "github.com/blevesearch/vellum/regexp"
+)
+
+func TestRegexpNumStates(t *testing.T) {
+	tests := []struct {
+		regexp string
+		states int
+	}{
+		{"a", 2},
+		{"abc", 4},
+		{"a*", 1},
+		{"[ab]*a[ab]", 4},
+	}
+	for _, test := range tests {
+		regex, err := velreg.New(test.regexp)
+		if err != nil {
+			t.Fatalf("error compiling regexp %s: %v", test.regexp, err)
+		}
+		got := regexpNumStates(regex)
+		if got != test.states {
+			t.Errorf("expected %d states for %s, got %d", test.states, test.regexp, got)
+		}
+	}
+}
+
+// TestRegexpNumStatesVellum pins the behavior of vellum which
+// regexpNumStates relies on: the states reachable from the start
+// are numbered from 1 without gaps, and only they can match.
+func TestRegexpNumStatesVellum(t *testing.T) {
+	for _, re := range []string{"a", "a*", "m.*", "foo.*bar", "[ab]*a[ab]{5}", "über|uber"} {
+		regex, err := velreg.New(re)
+		if err != nil {
+			t.Fatalf("error compiling regexp %s: %v", re, err)
+		}
+		seen := map[int]bool{regex.Start(): true}
+		stack := []int{regex.Start()}
+		for len(stack) > 0 {
+			s := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			for b := 0; b < 256; b++ {
+				next := regex.Accept(s, byte(b))
+				if next != 0 && !seen[next] {
+					seen[next] = true
+					stack = append(stack, next)
+				}
+			}
+		}
+		n := regexpNumStates(regex)
+		if len(seen) != n {
+			t.Errorf("expected %d reachable states for %s, got %d", n, re, len(seen))
+		}
+		for s := 1; s <= n; s++ {
+			if !seen[s] || !regex.CanMatch(s) {
+				t.Errorf("expected state %d of %s to be reachable and able to match", s, re)
+			}
+		}
+		if regex.CanMatch(0) || regex.CanMatch(n+1) {
+			t.Errorf("expected only the states of %s to be able to match", re)
+		}
+	}
+}
+
+func TestRegexpLimits(t *testing.T) {
+	idx, err := New("", map[string]interface{}{
+		ConfigRegexpMaxPatternLength: 16,
+		ConfigRegexpMaxDFAStates:     32,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mapAndUpdateDocument(t, idx, "a", map[string]interface{}{
+		"name": "marty",
+	})
+	reader, err := idx.Reader()
+	if err != nil {
+		t.Fatalf("error getting index reader: %v", err)
+	}
+	readerRegexp := reader.(index.IndexReaderRegexp)
+
+	tests := []struct {
+		regexp string
+		limit  string
+	}{
+		{"m.*", ""},
+		{"marty|martin|mary", ConfigRegexpMaxPatternLength},
+		// the DFA must remember the last 6 characters
+		{"[ab]*a[ab]{5}", ConfigRegexpMaxDFAStates},
+	}
+	for _, test := range tests {
+		for i := 0; i < 2; i++ {
+			// with and without the automaton
+			if i == 0 {
+				_, err = readerRegexp.FieldDictRegexp("name", test.regexp)
+			} else {
+				_, _, err = readerRegexp.FieldDictRegexpAutomaton("name", test.regexp)
+			}
+			if test.limit == "" {
+				if err != nil {
+					t.Errorf("unexpected error for %s: %v", test.regexp, err)
+				}
+				continue
+			}
+			var limitErr *RegexpLimitError
+			if !errors.As(err, &limitErr) {
+				t.Fatalf("expected limit error for %s, got %v", test.regexp, err)
+			}
+			if limitErr.Limit != test.limit || limitErr.Regexp != test.regexp {
+				t.Errorf("expected %s exceeded by %s, got %+v", test.limit, test.regexp, limitErr)
+			}
+		}
+	}
+
+	// regexps exceeding the states limit are only compiled once
+	stats := idx.(*Sear).Stats()
+	if stats.TotRegexpCacheMisses != 2 || stats.TotRegexpCacheHits != 2 {
+		t.Errorf("expected 2 misses and 2 hits, got %d and %d",
+			stats.TotRegexpCacheMisses, stats.TotRegexpCacheHits)
+	}
+}
+
+func TestRegexpCompiledSizeLimit(t *testing.T) {
+	if regexpCompiledSizeLimit(velreg.StateLimit) != velreg.DefaultLimit {
+		t.Errorf("expected vellum's default limit for vellum's states limit")
+	}
+
+	// a large program is rejected before its DFA is built
+	_, err := newRegexpWithLimit(`\p{L}+`, 32)
+	var limitErr *RegexpLimitError
+	if !errors.As(err, &limitErr) || limitErr.Max != 32 {
+		t.Fatalf("expected limit error, got %v", err)
+	}
+	_, err = velreg.NewWithLimit(`\p{L}+`, regexpCompiledSizeLimit(32))
+	if !errors.Is(err, velreg.ErrCompiledTooBig) {
+		t.Errorf("expected program to exceed the compiled size limit, got %v", err)
+	}
+
+	_, err = newRegexpWithLimit("[", 32)
+	if err == nil || errors.As(err, &limitErr) {
+		t.Errorf("expected compile error, got %v", err)
+	}
+}
+
+func TestRegexpLimitsSharedCache(t *testing.T) {
+	c := NewAutomatonCache()
+	_, err := c.Regexp("[ab]*a[ab]{5}")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the limit is checked before the result is cached, and
+	// the error is cached, rather than the regexp
+	for i := 0; i < 2; i++ {
+		regex, err := c.RegexpWithLimit("[ab]*a[ab]{5}", 32)
+		var limitErr *RegexpLimitError
+		if regex != nil || !errors.As(err, &limitErr) {
+			t.Fatalf("expected limit error, got %v", err)
+		}
+	}
+	stats := c.Stats()
+	if stats.RegexpMisses != 2 || stats.RegexpHits != 1 {
+		t.Errorf("expected 2 misses and 1 hit, got %+v", stats)
+	}
+
+	// a regexp cached for one index is still checked against the
+	// limits of another
+	idx, err := New("", map[string]interface{}{
+		ConfigRegexpMaxDFAStates: 32,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := idx.(*Sear)
+	s.reader.automata = c
+	reader, err := idx.Reader()
+	if err != nil {
+		t.Fatalf("error getting index reader: %v", err)
+	}
+	_, err = reader.(index.IndexReaderRegexp).FieldDictRegexp("name", "[ab]*a[ab]{5}")
+	var limitErr *RegexpLimitError
+	if !errors.As(err, &limitErr) {
+		t.Fatalf("expected limit error, got %v", err)
+	}
+}
+
+func TestRegexpTooManyStates(t *testing.T) {
+	idx, err := New("", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader, err := idx.Reader()
+	if err != nil {
+		t.Fatalf("error getting index reader: %v", err)
+	}
+
+	// exceeds the states vellum will build
+	_, err = reader.(index.IndexReaderRegexp).FieldDictRegexp("name", "[ab]*a[ab]{14}")
+	var limitErr *RegexpLimitError
+	if !errors.As(err, &limitErr) {
+		t.Fatalf("expected limit error, got %v", err)
+	}
+	if limitErr.Limit != ConfigRegexpMaxDFAStates || limitErr.Max != velreg.StateLimit {
+		t.Errorf("expected default states limit, got %+v", limitErr)
+	}
+}
+
+func TestRegexpLiteralPrefix(t *testing.T) {
+	tests := []struct {
+		regexp string
+		prefix string
+	}{
+		{"foo.*bar", "foo"},
+		{"foo", "foo"},
+		{"fo+", "fo"},
+		{"(foo|fob).*", "fo"},
+		{"über.*", "über"},
+		{".*foo", ""},
+		{"[fg]oo", ""},
+		{"(?i)foo", ""},
+		{"foo|bar", ""},
+		{"[", ""},
+	}
+	for _, test := range tests {
+		got := regexpLiteralPrefix(test.regexp)
+		if got != test.prefix {
+			t.Errorf("expected prefix '%s' for %s, got '%s'", test.prefix, test.regexp, got)
+		}
+	}
+}
+
+func TestFieldDictRegexpLiteralPrefix(t *testing.T) {
+	idx, err := New("", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mapAndUpdateDocument(t, idx, "a", map[string]interface{}{
+		"body": "football foobar foo fob bar barfoo über überall",
+	})
+	reader, err := idx.Reader()
+	if err != nil {
+		t.Fatalf("error getting index reader: %v", err)
+	}
+	readerRegexp := reader.(index.IndexReaderRegexp)
+
+	tests := []struct {
+		regexp     string
+		expected   []string
+		candidates int
+	}{
+		{"foo.*", []string{"foo", "foobar", "football"}, 3},
+		{"foo.*bar", []string{"foobar"}, 3},
+		{"fo.", []string{"fob", "foo"}, 4},
+		{"über.+", []string{"überall"}, 2},
+		{"x.*", nil, 0},
+		{".*foo", []string{"barfoo", "foo"}, 8},
+	}
+	for _, test := range tests {
+		fd, err := readerRegexp.FieldDictRegexp("body", test.regexp)
+		if err != nil {
+			t.Fatalf("error getting field dict regexp: %v", err)
+		}
+		if got := len(fd.(*FieldDict).terms); got != test.candidates {
+			t.Errorf("expected %d candidates for %s, got %d", test.candidates, test.regexp, got)
+		}
+		assertTermDictionary(t, fd, test.expected)
+	}
+}
@@ -0,0 +1,188 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//              http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sear
+
+import (
+	"sync/atomic"
+)
+
+// Stats is a point-in-time copy of the operational
+// metrics of a Sear index.
+type Stats struct {
+	TotUpdates uint64
+	TotDeletes uint64
+	TotBatches uint64
+
+	// time spent analyzing documents, in nanoseconds
+	TotAnalysisTime uint64
+
+	// fields and terms (unique per field) of analyzed documents
+	TotFieldsIndexed uint64
+	TotTermsIndexed  uint64
+	MaxFieldsPerDoc  uint64
+	MaxTermsPerDoc   uint64
+
+	TotRegexpCacheHits   uint64
+	TotRegexpCacheMisses uint64
+	TotLevCacheHits      uint64
+	TotLevCacheMisses    uint64
+	TotFuzzyEvaluations  uint64
+	TotTermLookups       uint64
+}
+
+// ToMap returns the stats in the form used by StatsMap.
+func (s Stats) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"TotUpdates":           s.TotUpdates,
+		"TotDeletes":           s.TotDeletes,
+		"TotBatches":           s.TotBatches,
+		"TotAnalysisTime":      s.TotAnalysisTime,
+		"TotFieldsIndexed":     s.TotFieldsIndexed,
+		"TotTermsIndexed":      s.TotTermsIndexed,
+		"MaxFieldsPerDoc":      s.MaxFieldsPerDoc,
+		"MaxTermsPerDoc":       s.MaxTermsPerDoc,
+		"TotRegexpCacheHits":   s.TotRegexpCacheHits,
+		"TotRegexpCacheMisses": s.TotRegexpCacheMisses,
+		"TotLevCacheHits":      s.TotLevCacheHits,
+		"TotLevCacheMisses":    s.TotLevCacheMisses,
+		"TotFuzzyEvaluations":  s.TotFuzzyEvaluations,
+		"TotTermLookups":       s.TotTermLookups,
+	}
+}
+
+// stats are updated by the goroutine using the index,
+// but may be read concurrently by monitoring
+type stats struct {
+	totUpdates atomic.Uint64
+	totDeletes atomic.Uint64
+	totBatches atomic.Uint64
+
+	totAnalysisTime  atomic.Uint64
+	totFieldsIndexed atomic.Uint64
+	totTermsIndexed  atomic.Uint64
+	maxFieldsPerDoc  atomic.Uint64
+	maxTermsPerDoc   atomic.Uint64
+
+	totRegexpCacheHits   atomic.Uint64
+	totRegexpCacheMisses atomic.Uint64
+	totLevCacheHits      atomic.Uint64
+	totLevCacheMisses    atomic.Uint64
+	totFuzzyEvaluations  atomic.Uint64
+	totTermLookups       atomic.Uint64
+}
+
+// stats methods are no-ops when stats are disabled (nil)
+
+func (s *stats) updated() {
+	if s != nil {
+		s.totUpdates.Add(1)
+	}
+}
+
+func (s *stats) deleted() {
+	if s != nil {
+		s.totDeletes.Add(1)
+	}
+}
+
+func (s *stats) batched() {
+	if s != nil {
+		s.totBatches.Add(1)
+	}
+}
+
+func (s *stats) regexpCacheHit() {
+	if s != nil {
+		s.totRegexpCacheHits.Add(1)
+	}
+}
+
+func (s *stats) regexpCacheMiss() {
+	if s != nil {
+		s.totRegexpCacheMisses.Add(1)
+	}
+}
+
+func (s *stats) levCacheHit() {
+	if s != nil {
+		s.totLevCacheHits.Add(1)
+	}
+}
+
+func (s *stats) levCacheMiss() {
+	if s != nil {
+		s.totLevCacheMisses.Add(1)
+	}
+}
+
+func (s *stats) fuzzyEvaluation() {
+	if s != nil {
+		s.totFuzzyEvaluations.Add(1)
+	}
+}
+
+func (s *stats) termLookup() {
+	if s != nil {
+		s.totTermLookups.Add(1)
+	}
+}
+
+func (s *stats) analyzed(d *Document, nanos uint64) {
+	if s == nil {
+		return
+	}
+	s.totAnalysisTime.Add(nanos)
+
+	fields := uint64(len(d.fieldNames))
+	var terms uint64
+	for _, atf := range d.fieldTokenFreqs {
+		terms += uint64(len(atf))
+	}
+	s.totFieldsIndexed.Add(fields)
+	s.totTermsIndexed.Add(terms)
+	storeMax(&s.maxFieldsPerDoc, fields)
+	storeMax(&s.maxTermsPerDoc, terms)
+}
+
+func storeMax(v *atomic.Uint64, n uint64) {
+	for {
+		cur := v.Load()
+		if n <= cur || v.CompareAndSwap(cur, n) {
+			return
+		}
+	}
+}
+
+func (s *stats) snapshot() Stats {
+	if s == nil {
+		return Stats{}
+	}
+	return Stats{
+		TotUpdates:           s.totUpdates.Load(),
+		TotDeletes:           s.totDeletes.Load(),
+		TotBatches:           s.totBatches.Load(),
+		TotAnalysisTime:      s.totAnalysisTime.Load(),
+		TotFieldsIndexed:     s.totFieldsIndexed.Load(),
+		TotTermsIndexed:      s.totTermsIndexed.Load(),
+		MaxFieldsPerDoc:      s.maxFieldsPerDoc.Load(),
+		MaxTermsPerDoc:       s.maxTermsPerDoc.Load(),
+		TotRegexpCacheHits:   s.totRegexpCacheHits.Load(),
+		TotRegexpCacheMisses: s.totRegexpCacheMisses.Load(),
+		TotLevCacheHits:      s.totLevCacheHits.Load(),
+		TotLevCacheMisses:    s.totLevCacheMisses.Load(),
+		TotFuzzyEvaluations:  s.totFuzzyEvaluations.Load(),
+		TotTermLookups:       s.totTermLookups.Load(),
+	}
+}
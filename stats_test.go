@@ -0,0 +1,114 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//              http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sear
+
+import (
+	"testing"
+
+	index "github.com/blevesearch/bleve_index_api"
+)
+
+func TestStats(t *testing.T) {
+	idx, err := New("", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := idx.(*Sear)
+
+	statsMap := idx.StatsMap()
+	if statsMap == nil {
+		t.Fatalf("expected non-nil stats map")
+	}
+	if statsMap["TotUpdates"] != uint64(0) {
+		t.Errorf("expected 0 updates, got %v", statsMap["TotUpdates"])
+	}
+
+	mapAndUpdateDocument(t, idx, "a", map[string]interface{}{
+		"name":   "marty",
+		"slogan": "code match code",
+	})
+	mapAndUpdateDocument(t, idx, "b", map[string]interface{}{
+		"name": "steve",
+	})
+	err = idx.Delete("b")
+	if err != nil {
+		t.Fatalf("error deleting doc: %v", err)
+	}
+	mapAndUpdateDocument(t, idx, "c", map[string]interface{}{
+		"name": "gas",
+	})
+
+	reader, err := idx.Reader()
+	if err != nil {
+		t.Fatalf("error getting index reader: %v", err)
+	}
+	_, err = reader.TermFieldReader(nil, []byte("gas"), "name", false, false, false)
+	if err != nil {
+		t.Fatalf("error getting term field reader: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		_, err = reader.(index.IndexReaderRegexp).FieldDictRegexp("name", "g.*")
+		if err != nil {
+			t.Fatalf("error getting field dict regexp: %v", err)
+		}
+	}
+	fd, err := reader.(index.IndexReaderFuzzy).FieldDictFuzzy("name", "gap", 1, "")
+	if err != nil {
+		t.Fatalf("error getting field dict fuzzy: %v", err)
+	}
+	assertTermDictionary(t, fd, []string{"gas"})
+
+	stats := s.Stats()
+	expected := Stats{
+		TotUpdates:           3,
+		TotDeletes:           1,
+		TotAnalysisTime:      stats.TotAnalysisTime,
+		TotFieldsIndexed:     7, // including _all
+		TotTermsIndexed:      10,
+		MaxFieldsPerDoc:      3,
+		MaxTermsPerDoc:       6,
+		TotRegexpCacheHits:   2,
+		TotRegexpCacheMisses: 1,
+		TotLevCacheMisses:    1,
+		TotFuzzyEvaluations:  1,
+		TotTermLookups:       1,
+	}
+	if stats != expected {
+		t.Errorf("expected stats %+v, got %+v", expected, stats)
+	}
+
+	statsMap = idx.StatsMap()
+	if statsMap["TotUpdates"] != uint64(3) || statsMap["TotTermLookups"] != uint64(1) {
+		t.Errorf("expected stats map to match typed stats, got %v", statsMap)
+	}
+}
+
+func TestStatsDisabled(t *testing.T) {
+	idx, err := New("", map[string]interface{}{
+		ConfigStatsEnabled: false,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mapAndUpdateDocument(t, idx, "a", map[string]interface{}{
+		"name": "marty",
+	})
+	if statsMap := idx.StatsMap(); statsMap != nil {
+		t.Errorf("expected nil stats map, got %v", statsMap)
+	}
+	if stats := idx.(*Sear).Stats(); stats != (Stats{}) {
+		t.Errorf("expected zero stats, got %+v", stats)
+	}
+}
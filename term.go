@@ -21,32 +21,59 @@ import (
 	index "github.com/blevesearch/bleve_index_api"
 )
 
+type termFieldPosting struct {
+	num int
+	tf  *index.TokenFreq
+	len int
+}
+
 type TermFieldReader struct {
-	tf                 *index.TokenFreq
-	len                int
-	done               bool
+	postings           []termFieldPosting
+	next               int
 	includeFreq        bool
 	includeNorm        bool
 	includeTermVectors bool
+
+	// document frequency reported by Count, when not len(postings)
+	count    uint64
+	hasCount bool
+
+	// backing for the common single document case, avoiding a separate
+	// slice allocation (FieldDictContains and VectorFieldReader do the same)
+	one [1]termFieldPosting
 }
 
 var termFieldReaderEmpty = NewTermFieldReaderEmpty()
 
 func NewTermFieldReaderEmpty() *TermFieldReader {
-	return &TermFieldReader{
-		done: true,
-	}
+	return &TermFieldReader{}
 }
 
 func NewTermFieldReaderFromTokenFreqAndLen(tf *index.TokenFreq, l int, includeFreq, includeNorm,
 	includeTermVectors bool) *TermFieldReader {
-	return &TermFieldReader{
-		tf:                 tf,
-		len:                l,
+	rv := newTermFieldReader(includeFreq, includeNorm, includeTermVectors)
+	rv.addPosting(0, tf, l)
+	return rv
+}
+
+func newTermFieldReader(includeFreq, includeNorm, includeTermVectors bool) *TermFieldReader {
+	rv := &TermFieldReader{
 		includeFreq:        includeFreq,
 		includeNorm:        includeNorm,
 		includeTermVectors: includeTermVectors,
 	}
+	rv.postings = rv.one[:0]
+	return rv
+}
+
+// addPosting adds the term usage in document num,
+// postings must be added in doc number order
+func (t *TermFieldReader) addPosting(num int, tf *index.TokenFreq, l int) {
+	t.postings = append(t.postings, termFieldPosting{
+		num: num,
+		tf:  tf,
+		len: l,
+	})
 }
 
 func normForLen(l int) float64 {
@@ -54,23 +81,24 @@ func normForLen(l int) float64 {
 }
 
 func (t *TermFieldReader) Next(preAlloced *index.TermFieldDoc) (*index.TermFieldDoc, error) {
-	if t.done {
+	if t.next >= len(t.postings) {
 		return nil, nil
 	}
+	p := &t.postings[t.next]
 	rv := preAlloced
 	if rv == nil {
 		rv = &index.TermFieldDoc{}
 	}
-	rv.Term = string(t.tf.Term)
-	rv.ID = internalDocID
+	rv.Term = string(p.tf.Term)
+	rv.ID = internalDocIDs[p.num]
 	if t.includeFreq {
-		rv.Freq = uint64(t.tf.Frequency())
+		rv.Freq = uint64(p.tf.Frequency())
 	}
 	if t.includeNorm {
-		rv.Norm = normForLen(t.len)
+		rv.Norm = normForLen(p.len)
 	}
 	if t.includeTermVectors {
-		locs := t.tf.Locations
+		locs := p.tf.Locations
 		if cap(rv.Vectors) < len(locs) {
 			rv.Vectors = make([]*index.TermFieldVector, len(locs))
 			backing := make([]index.TermFieldVector, len(locs))
@@ -89,29 +117,27 @@ func (t *TermFieldReader) Next(preAlloced *index.TermFieldDoc) (*index.TermField
 			}
 		}
 	}
-	t.done = true
+	t.next++
 	return rv, nil
 }
 
 // Advance resets the enumeration at specified document or its immediate
 // follower.
 func (t *TermFieldReader) Advance(id index.IndexInternalID, preAlloced *index.TermFieldDoc) (*index.TermFieldDoc, error) {
-	if t.done {
-		return nil, nil
-	}
-	if bytes.Compare(id, internalDocID) > 0 {
-		// seek is after our internal id
-		t.done = true
-		return nil, nil
+	// seek from the start so backwards seeks are honoured
+	t.next = 0
+	for t.next < len(t.postings) && bytes.Compare(internalDocIDs[t.postings[t.next].num], id) < 0 {
+		// seek is after this internal id
+		t.next++
 	}
 	return t.Next(preAlloced)
 }
 
 func (t *TermFieldReader) Count() uint64 {
-	if t.tf != nil {
-		return 1
+	if t.hasCount {
+		return t.count
 	}
-	return 0
+	return uint64(len(t.postings))
 }
 
 func (t *TermFieldReader) Close() error {
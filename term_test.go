@@ -15,6 +15,7 @@
 package sear
 
 import (
+	"bytes"
 	"reflect"
 	"testing"
 
@@ -142,3 +143,57 @@ func TestTermFieldReader(t *testing.T) {
 		t.Fatalf("error closing term field reader: %v", err)
 	}
 }
+
+func TestTermFieldReaderReuse(t *testing.T) {
+	idx, err := NewMulti(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mapAndUpdateDocument(t, idx, "a", map[string]interface{}{
+		"slogan": "match match code",
+	})
+	mapAndUpdateDocument(t, idx, "b", map[string]interface{}{
+		"slogan": "match",
+	})
+	reader, err := idx.Reader()
+	if err != nil {
+		t.Fatalf("error getting index reader: %v", err)
+	}
+	tfr, err := reader.TermFieldReader(nil, []byte("match"), "slogan", true, true, true)
+	if err != nil {
+		t.Fatalf("error getting term field reader: %v", err)
+	}
+
+	expected := []struct {
+		id     []byte
+		freq   uint64
+		starts []uint64
+	}{
+		{internalDocIDs[0], 2, []uint64{0, 6}},
+		{internalDocIDs[1], 1, []uint64{0}},
+	}
+	preAlloced := &index.TermFieldDoc{}
+	for _, exp := range expected {
+		tfd, err := tfr.Next(preAlloced)
+		if err != nil {
+			t.Fatalf("error getting next tfd: %v", err)
+		}
+		if tfd != preAlloced {
+			t.Fatalf("expected pre-allocated tfd to be reused")
+		}
+		if !bytes.Equal(exp.id, tfd.ID) || tfd.Term != "match" || tfd.Freq != exp.freq {
+			t.Errorf("expected %v with freq %d, got %#v", exp.id, exp.freq, tfd)
+		}
+		var starts []uint64
+		for _, v := range tfd.Vectors {
+			starts = append(starts, v.Start)
+		}
+		if !reflect.DeepEqual(exp.starts, starts) {
+			t.Errorf("expected vector starts %v, got %v", exp.starts, starts)
+		}
+	}
+	tfd, err := tfr.Next(preAlloced)
+	if err != nil || tfd != nil {
+		t.Errorf("expected end of term field reader, got %v, err: %v", tfd, err)
+	}
+}
@@ -21,20 +21,181 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
 
 	index "github.com/blevesearch/bleve_index_api"
 )
 
-func (d *Document) interpretVectorIfApplicable(field index.Field) int {
+func (d *Document) interpretVectorIfApplicable(field index.Field) fieldVector {
 	if vf, ok := field.(index.VectorField); ok {
-		return vf.Dims()
+		return fieldVector{
+			dims:       vf.Dims(),
+			similarity: vf.Similarity(),
+			vector:     vf.Vector(),
+		}
 	}
 
-	return 0
+	return fieldVector{}
+}
+
+// validate returns an error describing why the vector
+// can never match, the error is prefixed with the field
+func (v *fieldVector) validate() error {
+	if v.dims == 0 {
+		// not a vector field
+		return nil
+	}
+	if _, ok := index.SupportedVectorSimilarityMetrics[v.similarity]; !ok {
+		return fmt.Errorf("has unsupported similarity %s", v.similarity)
+	}
+	if len(v.vector) == 0 {
+		return fmt.Errorf("has no valid vector values")
+	}
+	if len(v.vector)%v.dims != 0 {
+		return fmt.Errorf("has %d values, which is not a multiple of its %d dims",
+			len(v.vector), v.dims)
+	}
+	for i, f := range v.vector {
+		if math.IsNaN(float64(f)) || math.IsInf(float64(f), 0) {
+			return fmt.Errorf("has invalid value %f at position %d", f, i)
+		}
+	}
+	if v.similarity == index.CosineSimilarity {
+	vectors:
+		for off := 0; off < len(v.vector); off += v.dims {
+			for _, f := range v.vector[off : off+v.dims] {
+				if f != 0 {
+					continue vectors
+				}
+			}
+			return fmt.Errorf("has a zero vector at position %d, which is invalid for %s similarity",
+				off, v.similarity)
+		}
+	}
+	return nil
 }
 
+// parseVectorFields parses ConfigVectorFields, sorted by name
+func parseVectorFields(v interface{}) ([]vectorFieldConfig, error) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected map, got %T", v)
+	}
+	rv := make([]vectorFieldConfig, 0, len(m))
+	for name, fv := range m {
+		fm, ok := fv.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("field %s expected map, got %T", name, fv)
+		}
+		dims, err := configInt(fm["dims"])
+		if err != nil {
+			return nil, fmt.Errorf("field %s dims %v", name, err)
+		}
+		if dims <= 0 {
+			return nil, fmt.Errorf("field %s dims %d out of range", name, dims)
+		}
+		vf := vectorFieldConfig{
+			name: name,
+			dims: dims,
+		}
+		if sim, ok := fm["similarity"]; ok {
+			vf.similarity, _ = sim.(string)
+			if _, ok := index.SupportedVectorSimilarityMetrics[vf.similarity]; !ok {
+				return nil, fmt.Errorf("field %s has unsupported similarity %v", name, sim)
+			}
+		}
+		rv = append(rv, vf)
+	}
+	sort.Slice(rv, func(i, j int) bool {
+		return rv[i].name < rv[j].name
+	})
+	return rv, nil
+}
+
+// check returns an error describing how the vector differs from
+// the expected vector field, the error is prefixed with the field
+func (v *fieldVector) check(expected *vectorFieldConfig) error {
+	if v.dims == 0 || len(v.vector) == 0 {
+		return fmt.Errorf("is missing")
+	}
+	if v.dims != expected.dims {
+		return fmt.Errorf("has %d dims, expected %d", v.dims, expected.dims)
+	}
+	if expected.similarity != "" && v.similarity != expected.similarity {
+		return fmt.Errorf("has similarity %s, expected %s", v.similarity, expected.similarity)
+	}
+	return nil
+}
+
+func (d *Document) fieldVector(fieldName string) (fieldVector, error) {
+	fieldIdx, err := d.fieldIndex(fieldName)
+	if err != nil {
+		return fieldVector{}, err
+	}
+	return d.vectors[fieldIdx], nil
+}
+
+// vectorScore returns the score of the closest document vector for
+// the query vector, as reported by scorch: the squared distance for
+// l2_norm, otherwise the inner product, which for cosine is of the
+// normalized vectors.  bleve normalizes both vectors for cosine,
+// but they are normalized here too, should they not have been.
+func vectorScore(similarity string, query, vectors []float32) float64 {
+	dims := len(query)
+	rv := subVectorScore(similarity, query, vectors[:dims])
+	for off := dims; off+dims <= len(vectors); off += dims {
+		score := subVectorScore(similarity, query, vectors[off:off+dims])
+		if betterVectorScore(similarity, score, rv) {
+			rv = score
+		}
+	}
+	return rv
+}
+
+func subVectorScore(similarity string, query, vector []float32) float64 {
+	switch similarity {
+	case index.InnerProduct:
+		var dot float32
+		for i, q := range query {
+			dot += q * vector[i]
+		}
+		return float64(dot)
+	case index.CosineSimilarity:
+		var dot, qq, vv float32
+		for i, q := range query {
+			dot += q * vector[i]
+			qq += q * q
+			vv += vector[i] * vector[i]
+		}
+		if qq == 0 || vv == 0 {
+			return 0
+		}
+		return float64(dot) / math.Sqrt(float64(qq)*float64(vv))
+	}
+	// l2_norm, the default
+	var dist float32
+	for i, q := range query {
+		d := q - vector[i]
+		dist += d * d
+	}
+	return float64(dist)
+}
+
+// betterVectorScore returns whether score a is a closer match than b
+func betterVectorScore(similarity string, a, b float64) bool {
+	if similarity == index.InnerProduct || similarity == index.CosineSimilarity {
+		return a > b
+	}
+	return a < b
+}
+
+// eligibleDocumentSelector collects the documents matching the
+// pre-filter of a kNN query, a new one is used for each query
 type eligibleDocumentSelector struct {
-	docNums []uint64
+	numDocs int
+	docNums []uint64 // sorted, without duplicates
 }
 
 func (eds *eligibleDocumentSelector) SegmentEligibleDocuments(segmentID int) index.EligibleDocumentList {
@@ -45,10 +206,20 @@ func (eds *eligibleDocumentSelector) SegmentEligibleDocuments(segmentID int) ind
 }
 
 func (eds *eligibleDocumentSelector) AddEligibleDocumentMatch(id index.IndexInternalID) error {
-	if len(id) > 0 {
-		eds.docNums = append(eds.docNums, 0)
+	num, ok := docNumForInternalID(id)
+	if !ok || num >= eds.numDocs {
+		return fmt.Errorf("invalid internal id: %v", id)
 	}
-
+	// matches normally arrive in order, so this is usually an append
+	i := sort.Search(len(eds.docNums), func(i int) bool {
+		return eds.docNums[i] >= uint64(num)
+	})
+	if i < len(eds.docNums) && eds.docNums[i] == uint64(num) {
+		return nil
+	}
+	eds.docNums = append(eds.docNums, 0)
+	copy(eds.docNums[i+1:], eds.docNums[i:])
+	eds.docNums[i] = uint64(num)
 	return nil
 }
 
@@ -80,88 +251,212 @@ func (edi *eligibleDocumentIterator) Next() (uint64, bool) {
 	return rv, true
 }
 
+// NewEligibleDocumentSelector returns an empty selector,
+// which accepts the internal ids of this reader's documents.
 func (r *Reader) NewEligibleDocumentSelector() index.EligibleDocumentSelector {
-	return &eligibleDocumentSelector{}
+	return &eligibleDocumentSelector{
+		numDocs: len(r.ds.docs),
+	}
+}
+
+// eligibleDocNums returns whether each doc number
+// is eligible, according to the selector
+func eligibleDocNums(selector index.EligibleDocumentSelector) (rv [MaxDocs]bool) {
+	it := selector.SegmentEligibleDocuments(0).Iterator()
+	for num, ok := it.Next(); ok; num, ok = it.Next() {
+		if num < MaxDocs {
+			rv[num] = true
+		}
+	}
+	return rv
+}
+
+// vectorSearchParams are the searchParams understood by VectorReader,
+// other params, intended for other indexes, are ignored.  As top k is
+// of little use with so few documents, these thresholds decide whether
+// a document matches instead.
+type vectorSearchParams struct {
+	// MinSimilarity applies to the dot_product and cosine metrics
+	MinSimilarity *float64 `json:"min_similarity,omitempty"`
+
+	// MaxDistance is the euclidean distance, for the l2_norm metric
+	MaxDistance *float64 `json:"max_distance,omitempty"`
+}
+
+func parseVectorSearchParams(searchParams json.RawMessage) (*vectorSearchParams, error) {
+	var rv vectorSearchParams
+	if len(searchParams) > 0 {
+		err := json.Unmarshal(searchParams, &rv)
+		if err != nil {
+			return nil, fmt.Errorf("invalid vector search params: %v", err)
+		}
+	}
+	// similarities may be negative, but distances may not
+	if rv.MaxDistance != nil && *rv.MaxDistance < 0 {
+		return nil, fmt.Errorf("invalid vector search params: negative max_distance %v", *rv.MaxDistance)
+	}
+	return &rv, nil
+}
+
+// within returns whether the score, as returned by vectorScore,
+// satisfies the thresholds
+func (p *vectorSearchParams) within(similarity string, score float64) (bool, error) {
+	if similarity == index.InnerProduct || similarity == index.CosineSimilarity {
+		if p.MaxDistance != nil {
+			return false, fmt.Errorf("max_distance is not applicable to similarity %s", similarity)
+		}
+		return p.MinSimilarity == nil || score >= *p.MinSimilarity, nil
+	}
+	if p.MinSimilarity != nil {
+		return false, fmt.Errorf("min_similarity is not applicable to similarity %s", similarity)
+	}
+	if p.MaxDistance == nil {
+		return true, nil
+	}
+	// score is the squared distance
+	return score <= *p.MaxDistance*(*p.MaxDistance), nil
 }
 
 func (r *Reader) VectorReader(ctx context.Context, vector []float32,
 	field string, k int64, searchParams json.RawMessage,
 	selector index.EligibleDocumentSelector) (index.VectorReader, error) {
+	if k < 0 {
+		return nil, fmt.Errorf("invalid k: %d, must not be negative", k)
+	}
 	if selector != nil && selector.SegmentEligibleDocuments(0).Count() == 0 {
 		// if selector/filter is applicable but no eligible docs,
-		// then current document does not qualify
+		// then no document qualifies
 		return NewVectorFieldReaderEmpty(), nil
 	}
 
-	if r.s.doc == nil {
+	if k == 0 {
 		return NewVectorFieldReaderEmpty(), nil
 	}
 
-	dims, err := r.s.doc.VectorDims(field)
+	params, err := parseVectorSearchParams(searchParams)
 	if err != nil {
-		// only error is field doesn't exist in doc
-		return NewVectorFieldReaderEmpty(), nil
+		return nil, err
 	}
 
-	if k == 0 || dims != len(vector) {
-		// no match
-		return NewVectorFieldReaderEmpty(), nil
+	var eligible [MaxDocs]bool
+	if selector != nil {
+		eligible = eligibleDocNums(selector)
 	}
 
-	// searchParams not applicable for single document index
-
-	return NewVectorFieldReaderMatch(dims), nil
+	var rv *VectorFieldReader
+	for num, d := range r.ds.docs {
+		if selector != nil && !eligible[num] {
+			continue
+		}
+		fv, err := d.fieldVector(field)
+		if err != nil {
+			// only error is field doesn't exist in doc
+			continue
+		}
+		if fv.dims == 0 || fv.dims != len(vector) ||
+			len(fv.vector) == 0 || len(fv.vector)%fv.dims != 0 {
+			// no match
+			continue
+		}
+		score := vectorScore(fv.similarity, vector, fv.vector)
+		within, err := params.within(fv.similarity, score)
+		if err != nil {
+			return nil, err
+		}
+		if !within {
+			continue
+		}
+		if rv == nil {
+			rv = newVectorFieldReader()
+			rv.similarity = fv.similarity
+		}
+		rv.addMatch(num, score)
+	}
+	if rv == nil {
+		return NewVectorFieldReaderEmpty(), nil
+	}
+	if int64(len(rv.matches)) > k {
+		// keep the k closest matches, in doc number order
+		sort.SliceStable(rv.matches, func(i, j int) bool {
+			return betterVectorScore(rv.similarity, rv.matches[i].score, rv.matches[j].score)
+		})
+		rv.matches = rv.matches[:k]
+		sort.Slice(rv.matches, func(i, j int) bool {
+			return rv.matches[i].num < rv.matches[j].num
+		})
+	}
+	return rv, nil
 }
 
 // -----------------------------------------------------------------------------
 
+type vectorMatch struct {
+	num   int
+	score float64
+}
+
 type VectorFieldReader struct {
-	done bool
-	dims int
+	matches    []vectorMatch
+	next       int
+	similarity string
+
+	one [1]vectorMatch
 }
 
 func NewVectorFieldReaderEmpty() *VectorFieldReader {
-	return &VectorFieldReader{
-		done: true,
-	}
+	return &VectorFieldReader{}
 }
 
 func NewVectorFieldReaderMatch(dims int) *VectorFieldReader {
-	return &VectorFieldReader{
-		dims: dims,
-	}
+	rv := newVectorFieldReader()
+	rv.addMatch(0, 0)
+	return rv
+}
+
+func newVectorFieldReader() *VectorFieldReader {
+	rv := &VectorFieldReader{}
+	rv.matches = rv.one[:0]
+	return rv
+}
+
+// addMatch adds a matching document and its score,
+// matches must be added in doc number order
+func (v *VectorFieldReader) addMatch(num int, score float64) {
+	v.matches = append(v.matches, vectorMatch{
+		num:   num,
+		score: score,
+	})
 }
 
 func (v *VectorFieldReader) Next(preAlloced *index.VectorDoc) (*index.VectorDoc, error) {
-	if v.done {
+	if v.next >= len(v.matches) {
 		return nil, nil
 	}
 	rv := preAlloced
 	if rv == nil {
 		rv = &index.VectorDoc{}
 	}
-	rv.ID = internalDocID
-	v.done = true
+	m := &v.matches[v.next]
+	rv.ID = internalDocIDs[m.num]
+	rv.Score = m.score
+	v.next++
 	return rv, nil
 }
 
+// Advance resets the enumeration at specified document or its immediate
+// follower.
 func (v *VectorFieldReader) Advance(id index.IndexInternalID, preAlloced *index.VectorDoc) (*index.VectorDoc, error) {
-	if v.done {
-		return nil, nil
-	}
-	if bytes.Compare(id, internalDocID) > 0 {
-		// seek is after our internal id
-		v.done = true
-		return nil, nil
+	// seek from the start so backwards seeks are honoured
+	v.next = 0
+	for v.next < len(v.matches) && bytes.Compare(internalDocIDs[v.matches[v.next].num], id) < 0 {
+		// seek is after this internal id
+		v.next++
 	}
 	return v.Next(preAlloced)
 }
 
 func (v *VectorFieldReader) Count() uint64 {
-	if v.dims != 0 {
-		return 1
-	}
-	return 0
+	return uint64(len(v.matches))
 }
 
 func (v *VectorFieldReader) Close() error {
@@ -18,10 +18,26 @@
 package sear
 
 import (
+	"fmt"
+
 	index "github.com/blevesearch/bleve_index_api"
 )
 
-func (d *Document) interpretVectorIfApplicable(field index.Field) int {
+func (d *Document) interpretVectorIfApplicable(field index.Field) fieldVector {
+	// not applicable
+	return fieldVector{}
+}
+
+func (v *fieldVector) validate() error {
+	// not applicable
+	return nil
+}
+
+func parseVectorFields(v interface{}) ([]vectorFieldConfig, error) {
+	return nil, fmt.Errorf("vector fields require the vectors build tag")
+}
+
+func (v *fieldVector) check(expected *vectorFieldConfig) error {
 	// not applicable
-	return 0
+	return nil
 }
@@ -0,0 +1,697 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//              http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build vectors
+// +build vectors
+
+package sear
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	index "github.com/blevesearch/bleve_index_api"
+)
+
+type testVectorField struct {
+	*testField
+	vector     []float32
+	dims       int
+	similarity string
+}
+
+func newTestVectorField(name, similarity string, dims int, vector []float32) *testVectorField {
+	return &testVectorField{
+		testField:  newTestField(name, nil),
+		vector:     vector,
+		dims:       dims,
+		similarity: similarity,
+	}
+}
+
+func (t *testVectorField) Analyze() {}
+
+func (t *testVectorField) Vector() []float32 {
+	return t.vector
+}
+
+func (t *testVectorField) Dims() int {
+	return t.dims
+}
+
+func (t *testVectorField) Similarity() string {
+	return t.similarity
+}
+
+func (t *testVectorField) IndexOptimizedFor() string {
+	return index.DefaultIndexOptimization
+}
+
+// testBase64VectorField is like bleve's vector_base64 fields, its
+// value is the base64 encoding, and its vector is already decoded
+type testBase64VectorField struct {
+	*testVectorField
+}
+
+func newTestBase64VectorField(name, similarity string, dims int, vector []float32) *testBase64VectorField {
+	buf := make([]byte, 4*len(vector))
+	for i, v := range vector {
+		binary.LittleEndian.PutUint32(buf[i*4:], math.Float32bits(v))
+	}
+	rv := &testBase64VectorField{
+		testVectorField: newTestVectorField(name, similarity, dims, vector),
+	}
+	rv.val = []byte(base64.StdEncoding.EncodeToString(buf))
+	return rv
+}
+
+func (t *testBase64VectorField) EncodedFieldType() byte {
+	return 'e'
+}
+
+func newTestVectorIndex(t *testing.T, similarity string, vectors ...[]float32) index.IndexReader {
+	return newTestMultiVectorIndex(t, similarity, 0, vectors...)
+}
+
+// newTestMultiVectorIndex indexes a document per vector, dims 0 uses the
+// length of each vector, otherwise each has multiple vectors of dims
+func newTestMultiVectorIndex(t *testing.T, similarity string, dims int, vectors ...[]float32) index.IndexReader {
+	idx, err := NewMulti(max(len(vectors), 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range vectors {
+		doc := newTestDoc(string(rune('a' + i)))
+		d := dims
+		if d == 0 {
+			d = len(v)
+		}
+		doc.AddField(newTestVectorField("vec", similarity, d, v))
+		err = idx.Update(doc)
+		if err != nil {
+			t.Fatalf("error updating index: %v", err)
+		}
+	}
+	reader, err := idx.Reader()
+	if err != nil {
+		t.Fatalf("error getting index reader: %v", err)
+	}
+	return reader
+}
+
+type expectedVectorDoc struct {
+	num   int
+	score float64
+}
+
+func assertVectorReader(t *testing.T, vr index.VectorReader, expected []expectedVectorDoc) {
+	t.Helper()
+	if vr.Count() != uint64(len(expected)) {
+		t.Errorf("expected %d matches, got %d", len(expected), vr.Count())
+	}
+	for _, e := range expected {
+		vd, err := vr.Next(nil)
+		if err != nil {
+			t.Fatalf("error getting next vector doc: %v", err)
+		}
+		if vd == nil {
+			t.Fatalf("expected doc %d, got end of matches", e.num)
+		}
+		if string(vd.ID) != string(internalDocIDs[e.num]) {
+			t.Errorf("expected doc %d, got %v", e.num, vd.ID)
+		}
+		if math.Abs(vd.Score-e.score) > 1e-6 {
+			t.Errorf("expected doc %d score %f, got %f", e.num, e.score, vd.Score)
+		}
+	}
+	vd, err := vr.Next(nil)
+	if err != nil || vd != nil {
+		t.Errorf("expected end of matches, got %v, err: %v", vd, err)
+	}
+}
+
+func TestVectorReaderScores(t *testing.T) {
+	vectors := [][]float32{{1, 0}, {3, 4}, {0, 2}}
+	query := []float32{0, 1}
+
+	tests := []struct {
+		similarity string
+		k          int64
+		expected   []expectedVectorDoc
+	}{
+		{
+			similarity: index.EuclideanDistance,
+			k:          3,
+			expected:   []expectedVectorDoc{{0, 2}, {1, 18}, {2, 1}},
+		},
+		{
+			similarity: index.EuclideanDistance,
+			k:          2,
+			expected:   []expectedVectorDoc{{0, 2}, {2, 1}},
+		},
+		{
+			similarity: index.InnerProduct,
+			k:          2,
+			expected:   []expectedVectorDoc{{1, 4}, {2, 2}},
+		},
+		{
+			similarity: index.CosineSimilarity,
+			k:          2,
+			expected:   []expectedVectorDoc{{1, 0.8}, {2, 1}},
+		},
+		{
+			similarity: index.CosineSimilarity,
+			k:          1,
+			expected:   []expectedVectorDoc{{2, 1}},
+		},
+	}
+
+	for _, test := range tests {
+		reader := newTestVectorIndex(t, test.similarity, vectors...)
+		vr, err := reader.(index.VectorIndexReader).VectorReader(nil, query, "vec", test.k, nil, nil)
+		if err != nil {
+			t.Fatalf("error getting vector reader: %v", err)
+		}
+		assertVectorReader(t, vr, test.expected)
+	}
+
+	// dimensions must match
+	reader := newTestVectorIndex(t, index.EuclideanDistance, vectors...)
+	vr, err := reader.(index.VectorIndexReader).VectorReader(nil, []float32{1, 2, 3}, "vec", 3, nil, nil)
+	if err != nil {
+		t.Fatalf("error getting vector reader: %v", err)
+	}
+	assertVectorReader(t, vr, nil)
+
+	// k must not be negative
+	_, err = reader.(index.VectorIndexReader).VectorReader(nil, query, "vec", -1, nil, nil)
+	if err == nil {
+		t.Errorf("expected error for negative k")
+	}
+}
+
+func TestVectorReaderThreshold(t *testing.T) {
+	vectors := [][]float32{{1, 0}, {3, 4}, {0, 2}}
+	query := []float32{0, 1}
+
+	tests := []struct {
+		similarity string
+		params     string
+		expected   []expectedVectorDoc
+		err        bool
+	}{
+		{
+			similarity: index.EuclideanDistance,
+			params:     `{"max_distance": 1.5}`,
+			expected:   []expectedVectorDoc{{0, 2}, {2, 1}},
+		},
+		{
+			similarity: index.EuclideanDistance,
+			params:     `{"max_distance": 0.5}`,
+		},
+		{
+			similarity: index.EuclideanDistance,
+			params:     `{"ivf_nprobe_pct": 10}`,
+			expected:   []expectedVectorDoc{{0, 2}, {1, 18}, {2, 1}},
+		},
+		{
+			similarity: index.InnerProduct,
+			params:     `{"min_similarity": 2}`,
+			expected:   []expectedVectorDoc{{1, 4}, {2, 2}},
+		},
+		{
+			similarity: index.CosineSimilarity,
+			params:     `{"min_similarity": 0.9}`,
+			expected:   []expectedVectorDoc{{2, 1}},
+		},
+		{
+			similarity: index.CosineSimilarity,
+			params:     `{"max_distance": 1}`,
+			err:        true,
+		},
+		{
+			similarity: index.EuclideanDistance,
+			params:     `{"min_similarity": 1}`,
+			err:        true,
+		},
+		{
+			similarity: index.EuclideanDistance,
+			params:     `{"max_distance": "far"}`,
+			err:        true,
+		},
+		{
+			similarity: index.EuclideanDistance,
+			params:     `{"max_distance": -1}`,
+			err:        true,
+		},
+		{
+			similarity: index.InnerProduct,
+			params:     `{"min_similarity": -10}`,
+			expected:   []expectedVectorDoc{{0, 0}, {1, 4}, {2, 2}},
+		},
+	}
+
+	for _, test := range tests {
+		reader := newTestVectorIndex(t, test.similarity, vectors...)
+		vr, err := reader.(index.VectorIndexReader).VectorReader(nil, query, "vec", 3,
+			[]byte(test.params), nil)
+		if test.err {
+			if err == nil {
+				t.Errorf("expected error for %s params %s", test.similarity, test.params)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("error getting vector reader: %v", err)
+		}
+		assertVectorReader(t, vr, test.expected)
+	}
+}
+
+func TestVectorReaderMultiVector(t *testing.T) {
+	query := []float32{0, 1}
+
+	// an array of vectors, flattened into a single field
+	reader := newTestMultiVectorIndex(t, index.EuclideanDistance, 2, []float32{3, 4, 0, 2, 1, 0})
+	vr, err := reader.(index.VectorIndexReader).VectorReader(nil, query, "vec", 1, nil, nil)
+	if err != nil {
+		t.Fatalf("error getting vector reader: %v", err)
+	}
+	assertVectorReader(t, vr, []expectedVectorDoc{{0, 1}})
+
+	// multiple occurrences of the field
+	first := []float32{3, 4}
+	idx, err := New("", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := newTestDoc("a")
+	doc.AddField(newTestVectorField("vec", index.InnerProduct, 2, first))
+	doc.AddField(newTestVectorField("vec", index.InnerProduct, 2, []float32{0, 5}))
+	doc.AddField(newTestVectorField("vec", index.InnerProduct, 2, []float32{1, 0}))
+	err = idx.Update(doc)
+	if err != nil {
+		t.Fatalf("error updating index: %v", err)
+	}
+	reader, err = idx.Reader()
+	if err != nil {
+		t.Fatalf("error getting index reader: %v", err)
+	}
+	vr, err = reader.(index.VectorIndexReader).VectorReader(nil, query, "vec", 1, nil, nil)
+	if err != nil {
+		t.Fatalf("error getting vector reader: %v", err)
+	}
+	assertVectorReader(t, vr, []expectedVectorDoc{{0, 5}})
+
+	// the best match decides the threshold
+	vr, err = reader.(index.VectorIndexReader).VectorReader(nil, query, "vec", 1,
+		[]byte(`{"min_similarity": 4.5}`), nil)
+	if err != nil {
+		t.Fatalf("error getting vector reader: %v", err)
+	}
+	assertVectorReader(t, vr, []expectedVectorDoc{{0, 5}})
+
+	if len(first) != 2 || cap(first) != 2 || first[0] != 3 || first[1] != 4 {
+		t.Errorf("expected field vector to be unmodified, got %v", first)
+	}
+}
+
+func TestVectorReaderBase64(t *testing.T) {
+	query := []float32{0, 1}
+
+	// base64 fields are scored like the vector fields they decode to
+	idx, err := NewMulti(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := []index.Field{
+		newTestBase64VectorField("vec", index.EuclideanDistance, 2, []float32{0, 2}),
+		// multiple vectors
+		newTestBase64VectorField("vec", index.EuclideanDistance, 2, []float32{3, 4, 0, 3}),
+		newTestVectorField("vec", index.EuclideanDistance, 2, []float32{0, 2}),
+	}
+	for i, f := range fields {
+		doc := newTestDoc(string(rune('a' + i)))
+		doc.AddField(f)
+		err = idx.Update(doc)
+		if err != nil {
+			t.Fatalf("error updating index: %v", err)
+		}
+	}
+	reader, err := idx.Reader()
+	if err != nil {
+		t.Fatalf("error getting index reader: %v", err)
+	}
+
+	vr, err := reader.(index.VectorIndexReader).VectorReader(nil, query, "vec", 3, nil, nil)
+	if err != nil {
+		t.Fatalf("error getting vector reader: %v", err)
+	}
+	assertVectorReader(t, vr, []expectedVectorDoc{{0, 1}, {1, 4}, {2, 1}})
+}
+
+// filteredVectorReader runs a kNN query pre-filtered by a term query,
+// as bleve does, collecting the filter matches into the selector
+func filteredVectorReader(t *testing.T, reader index.IndexReader, filterTerm string,
+	query []float32) index.VectorReader {
+	t.Helper()
+	selector := reader.(index.VectorIndexReader).NewEligibleDocumentSelector()
+	tfr, err := reader.TermFieldReader(nil, []byte(filterTerm), "tag", false, false, false)
+	if err != nil {
+		t.Fatalf("error getting term field reader: %v", err)
+	}
+	for tfd, err := tfr.Next(nil); tfd != nil; tfd, err = tfr.Next(nil) {
+		if err != nil {
+			t.Fatalf("error iterating term field reader: %v", err)
+		}
+		err = selector.AddEligibleDocumentMatch(tfd.ID)
+		if err != nil {
+			t.Fatalf("error adding eligible document: %v", err)
+		}
+	}
+	vr, err := reader.(index.VectorIndexReader).VectorReader(nil, query, "vec", 10, nil, selector)
+	if err != nil {
+		t.Fatalf("error getting vector reader: %v", err)
+	}
+	return vr
+}
+
+func TestVectorReaderFiltered(t *testing.T) {
+	query := []float32{0, 1}
+
+	idx, err := NewMulti(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	docs := []struct {
+		tag    string
+		vector []float32
+	}{
+		{"red", []float32{1, 0}},
+		{"blue", []float32{3, 4}},
+		{"red", []float32{0, 2}},
+	}
+	for i, d := range docs {
+		doc := newTestDoc(string(rune('a' + i)))
+		doc.AddField(newTestField("tag", []byte(d.tag)))
+		doc.AddField(newTestVectorField("vec", index.EuclideanDistance, 2, d.vector))
+		err = idx.Update(doc)
+		if err != nil {
+			t.Fatalf("error updating index: %v", err)
+		}
+	}
+	reader, err := idx.Reader()
+	if err != nil {
+		t.Fatalf("error getting index reader: %v", err)
+	}
+
+	assertVectorReader(t, filteredVectorReader(t, reader, "red", query),
+		[]expectedVectorDoc{{0, 2}, {2, 1}})
+	assertVectorReader(t, filteredVectorReader(t, reader, "blue", query),
+		[]expectedVectorDoc{{1, 18}})
+	assertVectorReader(t, filteredVectorReader(t, reader, "green", query), nil)
+
+	// selectors do not accumulate, and ignore duplicates
+	selector := reader.(index.VectorIndexReader).NewEligibleDocumentSelector()
+	for i := 0; i < 3; i++ {
+		err = selector.AddEligibleDocumentMatch(internalDocIDs[2])
+		if err != nil {
+			t.Fatalf("error adding eligible document: %v", err)
+		}
+	}
+	err = selector.AddEligibleDocumentMatch(internalDocIDs[0])
+	if err != nil {
+		t.Fatalf("error adding eligible document: %v", err)
+	}
+	list := selector.SegmentEligibleDocuments(0)
+	if list.Count() != 2 {
+		t.Errorf("expected 2 eligible documents, got %d", list.Count())
+	}
+	it := list.Iterator()
+	for _, expected := range []uint64{0, 2} {
+		num, ok := it.Next()
+		if !ok || num != expected {
+			t.Errorf("expected eligible doc %d, got %d, %t", expected, num, ok)
+		}
+	}
+	if reader.(index.VectorIndexReader).NewEligibleDocumentSelector().SegmentEligibleDocuments(0).Count() != 0 {
+		t.Errorf("expected new selector to be empty")
+	}
+
+	// ids are validated
+	for _, id := range []index.IndexInternalID{nil, internalDocIDs[3], {0, 1}} {
+		err = selector.AddEligibleDocumentMatch(id)
+		if err == nil {
+			t.Errorf("expected error adding invalid id %v", id)
+		}
+	}
+}
+
+func TestVectorReaderFilteredSingle(t *testing.T) {
+	query := []float32{0, 1}
+
+	idx, err := New("", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tag := range []string{"red", "blue"} {
+		doc := newTestDoc("a")
+		doc.AddField(newTestField("tag", []byte(tag)))
+		doc.AddField(newTestVectorField("vec", index.EuclideanDistance, 2, []float32{0, 2}))
+		err = idx.Update(doc)
+		if err != nil {
+			t.Fatalf("error updating index: %v", err)
+		}
+		reader, err := idx.Reader()
+		if err != nil {
+			t.Fatalf("error getting index reader: %v", err)
+		}
+		// the document fails the filter every time it doesn't match
+		assertVectorReader(t, filteredVectorReader(t, reader, "blue", query), map[string][]expectedVectorDoc{
+			"red":  nil,
+			"blue": {{0, 1}},
+		}[tag])
+		assertVectorReader(t, filteredVectorReader(t, reader, "red", query), map[string][]expectedVectorDoc{
+			"red":  {{0, 1}},
+			"blue": nil,
+		}[tag])
+	}
+}
+
+func TestKNNQueryHybrid(t *testing.T) {
+	query := []float32{0, 1}
+
+	idx, err := New("", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := newTestDoc("a")
+	doc.AddField(newTestField("tag", []byte("red")))
+	doc.AddField(newTestVectorField("vec", index.EuclideanDistance, 2, []float32{0, 3}))
+	err = idx.Update(doc)
+	if err != nil {
+		t.Fatalf("error updating index: %v", err)
+	}
+	reader, err := idx.Reader()
+	if err != nil {
+		t.Fatalf("error getting index reader: %v", err)
+	}
+
+	knn := &KNNQuery{Field: "vec", Vector: query, Boost: 2}
+	matched, score, err := knn.Match(context.Background(), reader)
+	if err != nil || !matched || score != 0.5 {
+		t.Errorf("expected match with score 0.5, got %t %f, err: %v", matched, score, err)
+	}
+
+	h, err := NewHybrid(&testTermQuery{field: "tag", term: "red"}, knn,
+		&LinearFusion{TextWeight: 1, VectorWeight: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	scores, err := h.Score(context.Background(), reader)
+	if err != nil {
+		t.Fatalf("error scoring hybrid: %v", err)
+	}
+	if !scores.TextMatched || scores.TextScore != 1 || !scores.VectorMatched ||
+		scores.VectorScore != 0.5 || scores.Score != 2 {
+		t.Errorf("unexpected hybrid scores %+v", scores)
+	}
+
+	// outside the threshold, only the text matches
+	knn.Params = []byte(`{"max_distance": 1}`)
+	scores, err = h.Score(context.Background(), reader)
+	if err != nil {
+		t.Fatalf("error scoring hybrid: %v", err)
+	}
+	if !scores.TextMatched || scores.VectorMatched || scores.Score != 1 {
+		t.Errorf("unexpected hybrid scores %+v", scores)
+	}
+
+	// exact l2_norm matches have the max score
+	matched, score, err = (&KNNQuery{Field: "vec", Vector: []float32{0, 3}}).Match(context.Background(), reader)
+	if err != nil || !matched || score != maxKNNScore {
+		t.Errorf("expected exact match, got %t %f, err: %v", matched, score, err)
+	}
+
+	_, _, err = (&KNNQuery{Field: "vec", Vector: query, K: -1}).Match(context.Background(), reader)
+	if err == nil {
+		t.Errorf("expected error for negative k")
+	}
+}
+
+func TestValidateVectors(t *testing.T) {
+	nan := float32(math.NaN())
+	tests := []struct {
+		field index.Field
+		err   string
+	}{
+		{
+			field: newTestVectorField("vec", index.CosineSimilarity, 2, []float32{0, 1, 1, 0}),
+		},
+		{
+			field: newTestVectorField("vec", index.EuclideanDistance, 2, []float32{0, 1, 0}),
+			err:   "document 'a' vector field 'vec' has 3 values, which is not a multiple of its 2 dims",
+		},
+		{
+			field: newTestVectorField("vec", index.EuclideanDistance, 2, []float32{0, nan}),
+			err:   "document 'a' vector field 'vec' has invalid value NaN at position 1",
+		},
+		{
+			field: newTestVectorField("vec", index.CosineSimilarity, 2, []float32{0, 1, 0, 0}),
+			err:   "document 'a' vector field 'vec' has a zero vector at position 2, which is invalid for cosine similarity",
+		},
+		{
+			field: newTestVectorField("vec", index.InnerProduct, 2, []float32{0, 0}),
+		},
+		{
+			field: newTestVectorField("vec", "hamming", 2, []float32{0, 1}),
+			err:   "document 'a' vector field 'vec' has unsupported similarity hamming",
+		},
+		{
+			field: newTestVectorField("vec", index.EuclideanDistance, 2, nil),
+			err:   "document 'a' vector field 'vec' has no valid vector values",
+		},
+	}
+
+	for _, test := range tests {
+		idx, err := New("", map[string]interface{}{
+			ConfigValidateVectors: true,
+		}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		doc := newTestDoc("a")
+		doc.AddField(newTestField("name", []byte("marty")))
+		doc.AddField(test.field)
+		err = idx.Update(doc)
+		if test.err == "" {
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != test.err {
+			t.Errorf("expected error %q, got %v", test.err, err)
+		}
+		reader, err := idx.Reader()
+		if err != nil {
+			t.Fatalf("error getting index reader: %v", err)
+		}
+		assertEmptyIndex(t, reader)
+	}
+
+	// invalid vectors are indexed without validation
+	idx, err := New("", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := newTestDoc("a")
+	doc.AddField(newTestVectorField("vec", index.EuclideanDistance, 2, []float32{0, nan}))
+	err = idx.Update(doc)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestVectorFields(t *testing.T) {
+	invalid := []interface{}{
+		map[string]interface{}{"vec": 3},
+		map[string]interface{}{"vec": map[string]interface{}{}},
+		map[string]interface{}{"vec": map[string]interface{}{"dims": 0}},
+		map[string]interface{}{"vec": map[string]interface{}{"dims": 2, "similarity": "hamming"}},
+	}
+	for _, fields := range invalid {
+		_, err := New("", map[string]interface{}{
+			ConfigVectorFields: fields,
+		}, nil)
+		if err == nil {
+			t.Errorf("expected error for vector fields %v", fields)
+		}
+	}
+
+	tests := []struct {
+		field index.Field
+		err   string
+	}{
+		{
+			field: newTestVectorField("vec", index.CosineSimilarity, 2, []float32{0, 1}),
+		},
+		{
+			// bleve drops vectors which do not match the mapping
+			field: newTestField("name", []byte("marty")),
+			err:   "document 'a' vector field 'vec' is missing",
+		},
+		{
+			field: newTestVectorField("vec", index.CosineSimilarity, 3, []float32{0, 1, 0}),
+			err:   "document 'a' vector field 'vec' has 3 dims, expected 2",
+		},
+		{
+			field: newTestVectorField("vec", index.EuclideanDistance, 2, []float32{0, 1}),
+			err:   "document 'a' vector field 'vec' has similarity l2_norm, expected cosine",
+		},
+	}
+	for _, test := range tests {
+		idx, err := New("", map[string]interface{}{
+			ConfigVectorFields: map[string]interface{}{
+				"vec": map[string]interface{}{
+					"dims":       float64(2),
+					"similarity": index.CosineSimilarity,
+				},
+			},
+		}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		doc := newTestDoc("a")
+		doc.AddField(test.field)
+		err = idx.Update(doc)
+		if test.err == "" {
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != test.err {
+			t.Errorf("expected error %q, got %v", test.err, err)
+		}
+		reader, err := idx.Reader()
+		if err != nil {
+			t.Fatalf("error getting index reader: %v", err)
+		}
+		assertEmptyIndex(t, reader)
+	}
+}